@@ -0,0 +1,100 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/newestuser/scurl/lib"
+	"strconv"
+	"strings"
+)
+
+// expectations are the validation rules given on the command line, they apply to every target
+type expectations struct {
+	status       statusList
+	bodyContains string
+	bodyRegex    string
+	json         repeated
+	headers      repeated
+	maxBodySize  int
+}
+
+func (e *expectations) register(fs *flag.FlagSet) {
+	fs.Var(&e.status, "expect-status", "Comma separated status codes every response is expected to have (i.e. 200,201)")
+	fs.StringVar(&e.bodyContains, "expect-body", "", "Text every response body is expected to contain")
+	fs.StringVar(&e.bodyRegex, "expect-body-regex", "", "Regular expression every response body is expected to match")
+	fs.Var(&e.json, "expect-json", "JSON path and value every response body is expected to have, may be repeated (i.e. data.id=42)")
+	fs.Var(&e.headers, "expect-header", "Header every response is expected to have, may be repeated")
+	fs.IntVar(&e.maxBodySize, "max-body-size", 0, "Maximum expected size of every response body in bytes [0 = unlimited] (default 0)")
+}
+
+func (e *expectations) rules() ([]scurl.Rule, error) {
+	var rules []scurl.Rule
+	if len(e.status.codes) != 0 {
+		rules = append(rules, scurl.ExpectStatus(e.status.codes...))
+	}
+	if e.bodyContains != "" {
+		rules = append(rules, scurl.ExpectBodyContains(e.bodyContains))
+	}
+	if e.bodyRegex != "" {
+		rule, err := scurl.ExpectBodyRegex(e.bodyRegex)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	for _, v := range e.json.values {
+		parts := strings.SplitN(v, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf(`-expect-json '%s' does not match the "PATH=VALUE" format (i.e. data.id=42)`, v)
+		}
+		rules = append(rules, scurl.ExpectJSON(parts[0], parts[1]))
+	}
+	for _, name := range e.headers.values {
+		rules = append(rules, scurl.ExpectHeader(name))
+	}
+	if e.maxBodySize > 0 {
+		rules = append(rules, scurl.MaxBodySize(e.maxBodySize))
+	}
+
+	return rules, nil
+}
+
+type statusList struct {
+	codes []int
+}
+
+func (s *statusList) String() string {
+	codes := make([]string, len(s.codes))
+	for i, code := range s.codes {
+		codes[i] = strconv.Itoa(code)
+	}
+
+	return strings.Join(codes, ",")
+}
+
+// Set implements the flag.Value interface for a comma separated list of status codes.
+func (s *statusList) Set(val string) error {
+	for _, part := range strings.Split(val, ",") {
+		code, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || code < 100 || code > 599 {
+			return fmt.Errorf("status code '%s' is not valid", part)
+		}
+		s.codes = append(s.codes, code)
+	}
+
+	return nil
+}
+
+// repeated collects the values of a flag which may be repeated
+type repeated struct {
+	values []string
+}
+
+func (r *repeated) String() string {
+	return strings.Join(r.values, ", ")
+}
+
+func (r *repeated) Set(val string) error {
+	r.values = append(r.values, val)
+	return nil
+}
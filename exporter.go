@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"github.com/newestuser/scurl/lib"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// exporter serves the metrics of a running stress to Prometheus.
+type exporter struct {
+	client *scurl.ConcurrentClient
+
+	mu       sync.Mutex
+	metrics  *scurl.Metrics
+	second   time.Time // start of the second the achieved rate is being counted in
+	hits     int       // responses received in the current second
+	achieved float64   // responses received per second during the previous second
+}
+
+// serveMetrics starts serving the metrics of the stress on addr at /metrics.
+func serveMetrics(addr string, client *scurl.ConcurrentClient) (*exporter, error) {
+	e := &exporter{client: client, metrics: scurl.NewMetrics(), second: time.Now()}
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", e)
+	go func() {
+		if err := http.Serve(ln, mux); err != nil {
+			log.Println("metrics server:", err)
+		}
+	}()
+
+	return e, nil
+}
+
+func (e *exporter) add(r *scurl.Response) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.metrics.Add(r)
+	e.tick(time.Now())
+	e.hits++
+}
+
+// tick moves the achieved rate on to the second now falls in.
+func (e *exporter) tick(now time.Time) {
+	elapsed := now.Sub(e.second)
+	if elapsed < time.Second {
+		return
+	}
+	if elapsed >= 2*time.Second {
+		e.achieved = 0
+	} else {
+		e.achieved = float64(e.hits) / elapsed.Seconds()
+	}
+	e.second, e.hits = now, 0
+}
+
+func (e *exporter) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.tick(time.Now())
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	if err := e.metrics.WritePrometheus(w); err != nil {
+		return
+	}
+
+	gauge(w, "scurl_target_rate", "Requests per second the stress is configured to send.", e.client.HitsPerSecond())
+	gauge(w, "scurl_achieved_rate", "Responses per second received during the last second.", e.achieved)
+	gauge(w, "scurl_in_flight_requests", "Requests awaiting their response.", float64(e.client.InFlight()))
+	gauge(w, "scurl_active_workers", "Workers sending requests.", float64(e.client.Workers()))
+}
+
+func gauge(w http.ResponseWriter, name, help string, value float64) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n%s %s\n", name, help, name, name, strconv.FormatFloat(value, 'g', -1, 64))
+}
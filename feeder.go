@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"github.com/newestuser/scurl/lib"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// readFeeder creates the Feeder of the dataset at path in the given format, or the format based on its extension.
+func readFeeder(path, format, order, exhaustion string) (scurl.Feeder, error) {
+	switch scurl.FeedOrder(order) {
+	case scurl.SequentialFeed, scurl.RandomFeed, scurl.CircularFeed:
+	default:
+		return nil, fmt.Errorf("feeder order '%s' is not supported, supported orders are [sequential random circular]", order)
+	}
+	switch scurl.FeedExhaustion(exhaustion) {
+	case scurl.StopOnExhaustion, scurl.WrapOnExhaustion, scurl.ErrorOnExhaustion:
+	default:
+		return nil, fmt.Errorf("feeder exhaustion '%s' is not supported, supported behaviors are [stop wrap error]", exhaustion)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if format == "" {
+		format = "csv"
+		if ext := strings.ToLower(filepath.Ext(path)); ext == ".json" || ext == ".jsonl" {
+			format = "json"
+		}
+	}
+
+	var rows []scurl.Row
+	switch format {
+	case "csv":
+		rows, err = scurl.ReadCSVRows(f)
+	case "json":
+		rows, err = scurl.ReadJSONRows(f)
+	default:
+		return nil, fmt.Errorf("feeder format '%s' is not supported, supported formats are [csv json]", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%s: no rows", path)
+	}
+
+	return scurl.NewFeeder(rows, scurl.FeedOrder(order), scurl.FeedExhaustion(exhaustion)), nil
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"github.com/newestuser/scurl/lib"
+	"io"
+	"time"
+)
+
+// intervalReport prints a summary of the responses received in every interval of a stress, followed by the
+// summary of all the responses so far, as plain lines suited for logs.
+type intervalReport struct {
+	w      io.Writer
+	began  time.Time
+	last   time.Time // end of the previous interval
+	window *scurl.Metrics
+	total  *scurl.Metrics
+}
+
+func newIntervalReport(w io.Writer, total *scurl.Metrics) *intervalReport {
+	now := time.Now()
+
+	return &intervalReport{w: w, began: now, last: now, window: scurl.NewMetrics(), total: total}
+}
+
+func (i *intervalReport) add(r *scurl.Response) {
+	i.window.Add(r)
+}
+
+// print writes the summaries and starts a new interval.
+func (i *intervalReport) print() {
+	now := time.Now()
+	elapsed := now.Sub(i.began).Round(time.Second)
+
+	fmt.Fprintf(i.w, "[%s] interval: %s\n", elapsed, summary(i.window, now.Sub(i.last)))
+	fmt.Fprintf(i.w, "[%s] total:    %s\n", elapsed, summary(i.total, now.Sub(i.began)))
+
+	i.last = now
+	i.window = scurl.NewMetrics()
+}
+
+// summary describes the metrics of the responses received over the given period in a single line.
+func summary(m *scurl.Metrics, period time.Duration) string {
+	rps := 0.0
+	if period > 0 {
+		rps = float64(m.Trips) / period.Seconds()
+	}
+
+	return fmt.Sprintf("%d requests, %.1f/s, p50 %s, p90 %s, p99 %s, max %s, %d errors (%.2f%%)",
+		m.Trips, rps, m.Percentile(50), m.Percentile(90), m.Percentile(99), m.Slowest(), m.ErrorCount(), errorRate(m))
+}
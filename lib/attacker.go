@@ -4,19 +4,52 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 type stopper struct {
 	ctx        context.Context
 	cancelFunc context.CancelFunc
+
+	mu    sync.Mutex
+	ended chan struct{} // closed once no more hits should be scheduled
+	err   error         // the error which ended the attack, if any
 }
 
 func (s *stopper) Stop() {
 	s.cancelFunc()
 }
 
+// end stops scheduling hits while letting the hits in flight complete. A non-nil err is the reason the attack
+// ended early, only the first one is kept.
+func (s *stopper) end(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err == nil {
+		s.err = err
+	}
+	select {
+	case <-s.ended:
+	default:
+		close(s.ended)
+	}
+}
+
+func (s *stopper) Ended() <-chan struct{} {
+	return s.ended
+}
+
+func (s *stopper) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.err
+}
+
 func (s *stopper) Done() <-chan struct{} {
 	return s.ctx.Done()
 }
@@ -27,12 +60,13 @@ func NewStopper() *stopper {
 	return &stopper{
 		ctx:        ctx,
 		cancelFunc: cancel,
+		ended:      make(chan struct{}),
 	}
 }
 
 type Rate struct {
-	Freq int           // Frequency (number of occurrences) per ...
-	Per  time.Duration // Time unit, usually 1s
+	Freq int           `json:"freq"` // Frequency (number of occurrences) per ...
+	Per  time.Duration `json:"per"`  // Time unit, usually 1s
 }
 
 func (r *Rate) Interval() time.Duration {
@@ -49,6 +83,15 @@ func (r *Rate) RemainingSince(start time.Time, iteration uint64) time.Duration {
 	return timeSinceStart.Sub(time.Now())
 }
 
+// Pace implements Pacer for a constant rate.
+func (r *Rate) Pace(elapsed time.Duration, hits uint64) (time.Duration, bool) {
+	return r.Interval()*time.Duration(hits) - elapsed, false
+}
+
+func (r *Rate) HitsPerSecond(time.Duration) float64 {
+	return float64(r.Freq) / r.Per.Seconds()
+}
+
 func (r *Rate) String() string {
 	return fmt.Sprintf("%d/%v", r.Freq, r.Per)
 }
@@ -57,22 +100,48 @@ func (r *Rate) IsZero() bool {
 	return r.Freq == 0 || r.Per == 0
 }
 
+// errorBudget is shared by the attackers of a ConcurrentClient and tracks how many trips failed.
+type errorBudget struct {
+	max    int64 // maximum number of failed trips before the attack is stopped, 0 means unlimited
+	failed int64
+}
+
+// spend records a failed trip and reports whether the budget got exhausted.
+func (b *errorBudget) spend() bool {
+	failed := atomic.AddInt64(&b.failed, 1)
+
+	return b.max > 0 && failed >= b.max
+}
+
 type attacker struct {
-	workers int
-	client  *Client
-	stopper *stopper
-	logger  *logger
+	workers  int
+	client   *Client
+	stopper  *stopper
+	logger   *logger
+	errors   *errorBudget
+	inFlight *int64    // number of requests awaiting their response, shared by the attackers of a ConcurrentClient
+	active   *int64    // number of running workers, shared by the attackers of a ConcurrentClient
+	scenario *Scenario // played on every hit instead of hitting the targets of the Targeter
+	cookies  CookieMode
+
+	maxWorkers int // ceiling of the number of workers, 0 means unlimited
 }
 
-func (a *attacker) Attack(t *Target, r *Rate, du time.Duration) <-chan *Response {
+// tick schedules a hit
+type tick struct {
+	intended time.Time // when the hit was due, zero if it was not scheduled
+	late     bool      // the hit waited for a busy worker because the ceiling of workers was reached
+}
+
+func (a *attacker) Attack(t Targeter, p Pacer, du time.Duration) <-chan *Response {
 	workers := sync.WaitGroup{}
 	results := make(chan *Response)
-	ticks := make(chan uint64)
+	ticks := make(chan tick)
+	a.init()
 
-	if a.stopper == nil {
-		a.stopper = NewStopper()
+	if a.maxWorkers > 0 && a.workers > a.maxWorkers {
+		a.workers = a.maxWorkers
 	}
-
 	for i := 0; i < a.workers; i++ {
 		workers.Add(1)
 		go a.attack(t, ticks, &workers, results)
@@ -83,18 +152,41 @@ func (a *attacker) Attack(t *Target, r *Rate, du time.Duration) <-chan *Response
 		defer workers.Wait()
 		defer close(ticks)
 
-		hits := r.Hits(du)
 		count := uint64(0)
+		spawned := a.workers
 		began := time.Now()
+		timer := time.NewTimer(0)
+		if !timer.Stop() {
+			<-timer.C
+		}
 
 		for {
-			time.Sleep(r.RemainingSince(began, count))
-
 			select {
-			case ticks <- count:
-				if count++; count == hits {
+			case <-a.stopper.Ended():
+				return
+			default:
+			}
+
+			elapsed := time.Since(began)
+			wait, stop := p.Pace(elapsed, count)
+			if stop || (du > 0 && elapsed+wait >= du) {
+				return
+			}
+
+			if wait > 0 {
+				timer.Reset(wait)
+				select {
+				case <-timer.C:
+				case <-a.Done():
+					return
+				case <-a.stopper.Ended():
 					return
 				}
+			}
+
+			select {
+			case ticks <- tick{intended: began.Add(elapsed + wait)}:
+				count++
 
 			case _, ok := <-a.Done():
 				if !ok {
@@ -102,8 +194,23 @@ func (a *attacker) Attack(t *Target, r *Rate, du time.Duration) <-chan *Response
 				}
 
 			default:
-				workers.Add(1)
-				go a.attack(t, ticks, &workers, results)
+				// no worker is idle, a single new one takes the tick instead of one per spin of the loop, unless
+				// the ceiling is reached and the tick is late waiting for a busy worker, which is no schedule to
+				// keep at MaxRate
+				next := tick{intended: began.Add(elapsed + wait), late: p != MaxRate}
+				if a.maxWorkers == 0 || spawned < a.maxWorkers {
+					spawned++
+					workers.Add(1)
+					go a.attack(t, ticks, &workers, results)
+					next.late = false
+				}
+
+				select {
+				case ticks <- next:
+					count++
+				case <-a.Done():
+					return
+				}
 			}
 		}
 
@@ -112,17 +219,50 @@ func (a *attacker) Attack(t *Target, r *Rate, du time.Duration) <-chan *Response
 	return results
 }
 
-func (a *attacker) attack(t *Target, ticks <-chan uint64, workers *sync.WaitGroup, result chan *Response) {
+func (a *attacker) init() {
+	if a.stopper == nil {
+		a.stopper = NewStopper()
+	}
+	if a.client == nil {
+		a.client = &Client{logger: a.logger}
+	}
+	if a.cookies == ClientCookies {
+		a.client = a.client.withJar(newCookieJar())
+	}
+}
+
+// workerClient returns the client of a new worker. Outside of scenarios a worker is a virtual user hitting the
+// targets one after another.
+func (a *attacker) workerClient() *Client {
+	if a.cookies == WorkerCookies || (a.cookies == UserCookies && a.scenario == nil) {
+		return a.client.withJar(newCookieJar())
+	}
+
+	return a.client
+}
+
+func (a *attacker) attack(t Targeter, ticks <-chan tick, workers *sync.WaitGroup, result chan *Response) {
 	defer workers.Done()
+	if a.active != nil {
+		atomic.AddInt64(a.active, 1)
+		defer atomic.AddInt64(a.active, -1)
+	}
+
+	client := a.workerClient()
 
 	for {
 		select {
-		case _, ok := <-ticks:
+		case at, ok := <-ticks:
 			if !ok {
 				return
 			}
 
-			resp := a.hit(t)
+			if a.scenario != nil {
+				a.play(client, a.scenario, at, result)
+				continue
+			}
+
+			resp := a.hit(client, t, at)
 			if resp != nil {
 				result <- resp
 			}
@@ -135,31 +275,143 @@ func (a *attacker) attack(t *Target, ticks <-chan uint64, workers *sync.WaitGrou
 	}
 }
 
-func (a *attacker) hit(t *Target) *Response {
-	if a.client == nil {
-		a.client = &Client{logger: a.logger}
+func (a *attacker) hit(client *Client, tr Targeter, at tick) *Response {
+	t, err := tr.Next()
+	if err == io.EOF {
+		a.logger.debug("Ran out of targets")
+		a.stopper.end(nil)
+		return nil
+	}
+	if err != nil {
+		a.logger.debug("Failed picking target", err.Error())
+		a.stopper.end(err)
+		return nil
+	}
+
+	// the worker reads the body itself, a response waiting for the consumer would otherwise run into the timeout
+	// of the trip
+	resp := a.do(client, t, at)
+	if resp == nil || resp.Failed() {
+		return resp
 	}
+	if _, ok := a.read(resp); !ok {
+		return nil
+	}
+
+	return resp
+}
+
+// play runs the steps of the scenario as a new virtual user. The iteration is aborted by the first step which
+// failed or did not pass validation, which includes the extraction of its values.
+func (a *attacker) play(client *Client, s *Scenario, at tick, results chan<- *Response) {
+	if a.cookies == UserCookies {
+		client = client.withJar(newCookieJar())
+	}
+
+	vars := Row{}
+	if s.Feeder != nil {
+		row, err := s.Feeder.Next()
+		if err == io.EOF {
+			a.logger.debug("Ran out of rows")
+			a.stopper.end(nil)
+			return
+		}
+		if err != nil {
+			a.logger.debug("Failed feeding scenario", err.Error())
+			a.stopper.end(err)
+			return
+		}
+		for column, value := range row {
+			vars[column] = value
+		}
+	}
+
+	for _, step := range s.Steps {
+		t := *step.Target
+		if len(vars) != 0 {
+			t.row = vars
+		}
 
+		resp := a.do(client, &t, at)
+		if resp == nil {
+			return
+		}
+		// only the first step is scheduled, the later ones are sent as soon as the previous step is done
+		at = tick{}
+
+		if !resp.Failed() {
+			body, ok := a.read(resp)
+			if !ok {
+				return
+			}
+			if resp.Invalid == nil {
+				resp.Invalid = step.extract(resp.Response, body, vars)
+			}
+		}
+
+		results <- resp
+		if resp.Failed() || resp.Invalid != nil {
+			return
+		}
+	}
+}
+
+// do sends the request of the target, it returns nil if the attack was stopped or the request could not be built.
+func (a *attacker) do(client *Client, t *Target, at tick) *Response {
 	req, err := t.RequestWithContext(a.stopper.ctx)
 	if err != nil {
-		a.Stop()
+		a.logger.debug("Failed building request", err.Error())
+		a.stopper.end(err)
 		return nil
 	}
 
-	response, e := a.client.Do(req)
+	if a.inFlight != nil {
+		atomic.AddInt64(a.inFlight, 1)
+		defer atomic.AddInt64(a.inFlight, -1)
+	}
+
+	start := time.Now()
+	response, e := client.Do(req)
 
 	if e != nil {
 		var cancelError *CancelError
-		if !errors.As(e, &cancelError) {
-			a.logger.debug("Failed attack", e.Error())
+		if errors.As(e, &cancelError) {
+			return nil
 		}
-		a.Stop()
-		return response
+
+		a.logger.debug("Failed attack", e.Error())
+		a.spend()
+		return &Response{Time: time.Since(start), Sent: start, Intended: at.intended, Late: at.late, Target: t, Err: e}
 	}
 
+	response.Intended = at.intended
+	response.Late = at.late
+	response.Target = t
 	return response
 }
 
+// read reads the body of the response, a read which failed is charged to the error budget like a failed trip.
+// It reports false if the read was cut short by stopping the attack.
+func (a *attacker) read(resp *Response) ([]byte, bool) {
+	body := resp.read()
+	if resp.Canceled() {
+		return nil, false
+	}
+	if resp.Failed() {
+		a.logger.debug("Failed reading body", resp.Err.Error())
+		a.spend()
+	}
+
+	return body, true
+}
+
+// spend charges the error budget with a failed trip and stops the attack once the budget is exhausted.
+func (a *attacker) spend() {
+	if a.errors != nil && a.errors.spend() {
+		a.Stop()
+	}
+}
+
 func (a *attacker) Stop() {
 	a.stopper.Stop()
 }
@@ -1,8 +1,10 @@
 package scurl
 
 import (
+	"github.com/stretchr/testify/assert"
 	"net/http"
 	"net/http/httptest"
+	"sort"
 	"testing"
 	"time"
 )
@@ -54,3 +56,67 @@ func TestStopAttackWhenOrdered(t *testing.T) {
 		// if the attack does not stop this test will never finish
 	}
 }
+
+// countingPacer counts how often the attack consults its pacer
+type countingPacer struct {
+	Pacer
+	calls uint64
+}
+
+func (p *countingPacer) Pace(elapsed time.Duration, hits uint64) (time.Duration, bool) {
+	p.calls++
+	return p.Pacer.Pace(elapsed, hits)
+}
+
+func TestSpawnWorkerPerUnservedTick(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(50 * time.Millisecond)
+		}),
+	)
+	defer server.Close()
+
+	atk := &attacker{}
+	req, _ := NewTarget(server.URL)
+	pacer := &countingPacer{Pacer: &Rate{Freq: 100, Per: time.Second}}
+
+	hits := uint64(0)
+	for range atk.Attack(req, pacer, 200*time.Millisecond) {
+		hits++
+	}
+
+	// every pace sends a hit, taken by a new worker while the others are busy, except the last one ending the
+	// attack, the loop never spins spawning workers
+	if pacer.calls != hits+1 {
+		t.Fatalf("paced: %v, hits: %v", pacer.calls, hits)
+	}
+}
+
+func TestAttackRecordsIntendedSendTime(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+	)
+	defer server.Close()
+
+	req, _ := NewTarget(server.URL)
+	rate := &Rate{Freq: 100, Per: time.Second}
+
+	var intended []time.Time
+	for resp := range (&attacker{}).Attack(req, rate, 100*time.Millisecond) {
+		assert.False(t, resp.Sent.Before(resp.Intended))
+		assert.True(t, resp.ResponseTime() >= resp.Time)
+		intended = append(intended, resp.Intended)
+	}
+
+	// responses of different workers may arrive out of order
+	sort.Slice(intended, func(i, j int) bool { return intended[i].Before(intended[j]) })
+
+	assert.Equal(t, 10, len(intended))
+	for i := range intended {
+		assert.Equal(t, rate.Interval()*time.Duration(i), intended[i].Sub(intended[0]))
+	}
+}
@@ -1,10 +1,14 @@
 package scurl
 
 import (
+	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
-	"strings"
+	"net/http/httptrace"
+	"sync/atomic"
 	"time"
 )
 
@@ -30,34 +34,122 @@ func (c *Client) Do(r *http.Request) (*Response, error) {
 		c.Client = http.DefaultClient
 	}
 
+	h := &handshake{}
+	r = r.WithContext(httptrace.WithClientTrace(r.Context(), h.trace()))
+
 	start := time.Now()
 	httpResp, err := c.Client.Do(r)
 
 	if err != nil {
-		if strings.Contains(err.Error(), "context canceled") {
+		if errors.Is(err, context.Canceled) {
 			return nil, &CancelError{Err: err}
 		}
-		return nil, err
+		return nil, classify(err)
 	}
 
 	duration := time.Since(start)
 
-	return &Response{Response: httpResp, Time: duration}, nil
+	return &Response{Response: httpResp, Time: duration, Sent: start, Handshake: h.duration()}, nil
+}
+
+// handshake measures the TLS or QUIC handshake of the connection a request opened. The transport may still be
+// dialing once the trip is over, when the request was sent over a connection which became idle in the meantime.
+type handshake struct {
+	start, done int64 // in unix nanoseconds
 }
 
+func (h *handshake) trace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		TLSHandshakeStart: func() { atomic.StoreInt64(&h.start, time.Now().UnixNano()) },
+		TLSHandshakeDone:  func(tls.ConnectionState, error) { atomic.StoreInt64(&h.done, time.Now().UnixNano()) },
+	}
+}
+
+// duration returns the duration of the handshake, 0 if none completed
+func (h *handshake) duration() time.Duration {
+	start, done := atomic.LoadInt64(&h.start), atomic.LoadInt64(&h.done)
+	if start == 0 || done < start {
+		return 0
+	}
+
+	return time.Duration(done - start)
+}
+
+// Response is the result of a single trip. A trip that failed before a response was received carries the
+// failure in Err and has no *http.Response. Failures are reported as *TripError.
+//
+// Time is the service time of the trip, measured from the moment the request was actually sent. When an
+// attacker falls behind its schedule requests are sent later than intended, ResponseTime accounts for that
+// delay and corrects for the coordinated omission of the service time.
 type Response struct {
 	*http.Response
 	Time       time.Duration
+	Handshake  time.Duration // the TLS or QUIC handshake of the connection the request opened, 0 if it reused one
 	TotalBytes int
+	Err        error
+	Sent       time.Time        // when the request was sent
+	Intended   time.Time        // when the request was scheduled to be sent, zero if it was not scheduled
+	Late       bool             // the request waited for a busy worker because the ceiling of workers was reached
+	Target     *Target          // the target that was hit, nil if the request was not sent by an attacker
+	Invalid    *ValidationError // the first rule of the target the response violated, nil if it is valid
+}
+
+// ResponseTime returns the time from the moment the request was scheduled to be sent until the response was
+// received, or the service time if the request was not scheduled.
+func (r *Response) ResponseTime() time.Duration {
+	if r.Intended.IsZero() || r.Sent.Before(r.Intended) {
+		return r.Time
+	}
+
+	return r.Sent.Sub(r.Intended) + r.Time
+}
+
+func (r *Response) Failed() bool {
+	return r.Err != nil
+}
+
+// Canceled reports whether reading the body was cut short by stopping the attack, such trips are not counted.
+func (r *Response) Canceled() bool {
+	var cancelError *CancelError
+	return errors.As(r.Err, &cancelError)
 }
 
 func (r *Response) String() string {
+	if r.Failed() {
+		return fmt.Sprintf("{err=%s, time=%s}", r.Err, r.Time)
+	}
 	return fmt.Sprintf("{code=%s, time=%s}", r.Status, r.Time)
 }
+
+// ReadAndDiscard consumes the response body, marking the trip as failed with a BodyReadError if the body
+// cannot be read, or a TimeoutError if the trip timed out while reading it. A read cut short by stopping the
+// attack fails with a CancelError instead, see Canceled. Responses which were read are validated with the rules
+// of their target. The workers of an attack read the responses they return already, which makes this a no-op.
 func (r *Response) ReadAndDiscard() {
-	if bytes, err := ioutil.ReadAll(r.Body); err == nil {
-		r.TotalBytes = len(bytes)
+	r.read()
+}
+
+// read consumes and returns the response body the way ReadAndDiscard does. The body is read only once, it is
+// nil afterwards.
+func (r *Response) read() []byte {
+	if r.Response == nil || r.Body == nil {
+		return nil
+	}
+
+	bytes, err := ioutil.ReadAll(r.Body)
+	r.TotalBytes = len(bytes)
+	if errors.Is(err, context.Canceled) {
+		r.Err = &CancelError{Err: err}
+	} else if err != nil {
+		r.Err = &TripError{Class: BodyReadError, Err: err}
+		if errorClass(err) == TimeoutError {
+			r.Err = &TripError{Class: TimeoutError, Err: err}
+		}
+	} else if r.Target != nil {
+		r.Invalid = r.Target.validate(r.Response, bytes)
 	}
 
 	r.Body.Close()
+	r.Body = nil
+	return bytes
 }
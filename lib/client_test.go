@@ -1,10 +1,12 @@
 package scurl
 
 import (
+	"context"
 	"github.com/stretchr/testify/assert"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 )
 
 func TestExecuteGetRequest(t *testing.T) {
@@ -41,10 +43,7 @@ func TestExecuteRequestWithHeaders(t *testing.T) {
 
 func TestExecuteAndFail(t *testing.T) {
 	respHandler := func(w http.ResponseWriter, r *http.Request) {
-		// according to http spec there should be a location header for a redirect
-		// this will cause the response to fail
-		w.WriteHeader(http.StatusMovedPermanently)
-		w.Header().Del(`Location`)
+		writeMalformedResponse(w)
 	}
 
 	fs := httptest.NewServer(http.HandlerFunc(respHandler))
@@ -55,4 +54,66 @@ func TestExecuteAndFail(t *testing.T) {
 
 	assert.NotNil(t, respErr)
 	assert.Nil(t, resp)
+	assert.Equal(t, ProtocolError, ClassOf(respErr))
+}
+
+func TestResponseTimeIncludesSendDelay(t *testing.T) {
+	intended := time.Now()
+	resp := &Response{Time: 10 * time.Millisecond, Intended: intended, Sent: intended.Add(40 * time.Millisecond)}
+
+	assert.Equal(t, 10*time.Millisecond, resp.Time)
+	assert.Equal(t, 50*time.Millisecond, resp.ResponseTime())
+}
+
+func TestResponseTimeOfUnscheduledRequest(t *testing.T) {
+	resp := &Response{Time: 10 * time.Millisecond, Sent: time.Now()}
+
+	assert.Equal(t, 10*time.Millisecond, resp.ResponseTime())
+}
+
+// partialBodyServer starts a test server which sends part of the body and signals sent a while later, it holds
+// the rest until the request is gone
+func partialBodyServer(sent chan<- struct{}) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("partial"))
+		w.(http.Flusher).Flush()
+		time.Sleep(50 * time.Millisecond)
+		sent <- struct{}{}
+		<-r.Context().Done()
+	}))
+}
+
+func TestCancelReadingBody(t *testing.T) {
+	fs := partialBodyServer(make(chan struct{}, 1))
+	defer fs.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, fs.URL, nil)
+	resp, err := NewTimedClient().Do(req)
+	assert.Nil(t, err)
+
+	cancel()
+	resp.ReadAndDiscard()
+
+	assert.True(t, resp.Canceled())
+	assert.NotEqual(t, BodyReadError, ClassOf(resp.Err))
+}
+
+func TestStopWhileReadingBody(t *testing.T) {
+	sent := make(chan struct{}, 1)
+	fs := partialBodyServer(sent)
+	defer fs.Close()
+
+	client := NewConcurrentClient(FanOutOpt(1), RateOpt(&Rate{Freq: 1, Per: time.Second}), DurationOpt(time.Second))
+	target, _ := NewTarget(fs.URL)
+	go func() {
+		<-sent
+		client.Stop()
+	}()
+
+	trips := 0
+	for range client.DoReq(target) {
+		trips++
+	}
+	assert.Equal(t, 0, trips)
 }
@@ -0,0 +1,46 @@
+package scurl
+
+import (
+	"net/http"
+	"net/http/cookiejar"
+)
+
+// CookieMode is how the cookies set by the targets are kept and sent back with the following requests.
+type CookieMode string
+
+const (
+	NoCookies     CookieMode = "none"   // cookies are ignored, every request is anonymous
+	SharedCookies CookieMode = "shared" // a single jar shared by all the clients and workers
+	ClientCookies CookieMode = "client" // a jar per fan-out client
+	WorkerCookies CookieMode = "worker" // a jar per worker, kept for the lifetime of the worker
+	UserCookies   CookieMode = "user"   // a jar per virtual user, which is an iteration of a scenario or a worker
+)
+
+// CookiesOpt keeps the cookies set by the targets in jars according to the mode, which lets an attack hold the
+// sessions of cookie-based apps. Without it cookies are ignored.
+func CookiesOpt(mode CookieMode) func(*ConcurrentClient) {
+	return func(client *ConcurrentClient) {
+		if mode == "" {
+			mode = NoCookies
+		}
+
+		client.cookies = mode
+	}
+}
+
+func newCookieJar() http.CookieJar {
+	jar, _ := cookiejar.New(nil) // never fails without options
+	return jar
+}
+
+// withJar returns a client sharing the transport of c which keeps its cookies in jar.
+func (c *Client) withJar(jar http.CookieJar) *Client {
+	httpClient := http.DefaultClient
+	if c.Client != nil {
+		httpClient = c.Client
+	}
+
+	withJar := *httpClient
+	withJar.Jar = jar
+	return &Client{Client: &withJar, logger: c.logger}
+}
@@ -0,0 +1,106 @@
+package scurl
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// sessionServer starts a session for every request without a session cookie and counts the sessions started
+func sessionServer(sessions *int64) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if cookie, err := r.Cookie("session"); err == nil {
+			fmt.Fprint(w, cookie.Value)
+			return
+		}
+
+		id := atomic.AddInt64(sessions, 1)
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: fmt.Sprint(id), Path: "/"})
+		fmt.Fprint(w, "anonymous")
+	}))
+}
+
+func attackSessions(t *testing.T, mode CookieMode, fanOut int, attack func(*ConcurrentClient, string) <-chan *Response) (trips int, sessions int64) {
+	fs := sessionServer(&sessions)
+	defer fs.Close()
+
+	client := NewConcurrentClient(
+		FanOutOpt(fanOut),
+		RateOpt(&Rate{Freq: 20, Per: time.Second}),
+		DurationOpt(300*time.Millisecond),
+		CookiesOpt(mode),
+	)
+
+	for r := range attack(client, fs.URL) {
+		assert.Nil(t, r.Err)
+		assert.Nil(t, r.Invalid)
+		r.ReadAndDiscard()
+		trips++
+	}
+
+	return trips, atomic.LoadInt64(&sessions)
+}
+
+func hitURL(client *ConcurrentClient, url string) <-chan *Response {
+	target, _ := NewTarget(url)
+	return client.DoReq(target)
+}
+
+func TestIgnoreCookiesByDefault(t *testing.T) {
+	trips, sessions := attackSessions(t, "", 1, hitURL)
+
+	assert.True(t, trips > 1)
+	assert.Equal(t, int64(trips), sessions)
+}
+
+func TestKeepCookiesInJars(t *testing.T) {
+	for mode, maxSessions := range map[CookieMode]int64{SharedCookies: 2, ClientCookies: 2, WorkerCookies: 0} {
+		trips, sessions := attackSessions(t, mode, 2, hitURL)
+
+		assert.True(t, trips > 4, string(mode))
+		assert.True(t, sessions >= 1, string(mode))
+		assert.True(t, sessions < int64(trips), string(mode))
+		if maxSessions > 0 {
+			assert.True(t, sessions <= maxSessions, string(mode))
+		}
+	}
+}
+
+func TestKeepCookiesPerVirtualUser(t *testing.T) {
+	var iterations int64
+	trips, sessions := attackSessions(t, UserCookies, 1, func(client *ConcurrentClient, url string) <-chan *Response {
+		s, err := ReadScenario(strings.NewReader(fmt.Sprintf(`
+steps:
+  - name: login
+    url: %[1]s
+    expect:
+      body_contains: anonymous
+  - name: session
+    url: %[1]s
+    expect:
+      body_regex: '^\d+$'
+`, url)))
+		assert.Nil(t, err)
+
+		results := make(chan *Response)
+		go func() {
+			defer close(results)
+			for r := range client.DoScenario(s) {
+				if r.Target.Name == "login" {
+					atomic.AddInt64(&iterations, 1)
+				}
+				results <- r
+			}
+		}()
+		return results
+	})
+
+	assert.True(t, trips > 2)
+	assert.Equal(t, int64(trips), 2*sessions)
+	assert.Equal(t, atomic.LoadInt64(&iterations), sessions)
+}
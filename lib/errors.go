@@ -0,0 +1,91 @@
+package scurl
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"syscall"
+)
+
+// ErrorClass is the category of a failed trip.
+type ErrorClass string
+
+const (
+	DNSError               ErrorClass = "dns"
+	ConnectionRefusedError ErrorClass = "connection refused"
+	TLSError               ErrorClass = "tls handshake"
+	TimeoutError           ErrorClass = "timeout"
+	ConnectionResetError   ErrorClass = "connection reset"
+	ProtocolError          ErrorClass = "protocol"
+	BodyReadError          ErrorClass = "body read"
+	UnknownError           ErrorClass = "unknown"
+)
+
+// TripError is the error of a failed trip along with its category.
+type TripError struct {
+	Class ErrorClass
+	Err   error
+}
+
+func (e *TripError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *TripError) Unwrap() error {
+	return e.Err
+}
+
+// ClassOf returns the category of err, classifying it if it is not a *TripError already.
+func ClassOf(err error) ErrorClass {
+	var tripErr *TripError
+	if errors.As(err, &tripErr) {
+		return tripErr.Class
+	}
+
+	return classify(err).Class
+}
+
+func classify(err error) *TripError {
+	return &TripError{Class: errorClass(err), Err: err}
+}
+
+func errorClass(err error) ErrorClass {
+	var dnsErr *net.DNSError
+	var netErr net.Error
+	var recordErr tls.RecordHeaderError
+	var alertErr tls.AlertError
+	var verifyErr *tls.CertificateVerificationError
+	var authorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidErr x509.CertificateInvalidError
+
+	switch {
+	case errors.As(err, &dnsErr):
+		return DNSError
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return ConnectionRefusedError
+	case errors.As(err, &recordErr), errors.As(err, &alertErr), errors.As(err, &verifyErr),
+		errors.As(err, &authorityErr), errors.As(err, &hostnameErr), errors.As(err, &invalidErr):
+		return TLSError
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return TimeoutError
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.EPIPE), errors.Is(err, io.EOF),
+		errors.Is(err, io.ErrUnexpectedEOF):
+		return ConnectionResetError
+	}
+
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "tls:"):
+		return TLSError
+	case strings.Contains(msg, "malformed"), strings.Contains(msg, "unsupported protocol"),
+		strings.Contains(msg, "http2:"), strings.Contains(msg, "stopped after"):
+		return ProtocolError
+	}
+
+	return UnknownError
+}
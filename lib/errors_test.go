@@ -0,0 +1,90 @@
+package scurl
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func failedTrip(t *testing.T, client *Client, url string) error {
+	req, _ := http.NewRequest(`GET`, url, nil)
+
+	resp, err := client.Do(req)
+
+	assert.Nil(t, resp)
+	assert.NotNil(t, err)
+	return err
+}
+
+func TestClassifyConnectionRefused(t *testing.T) {
+	fs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	fs.Close()
+
+	err := failedTrip(t, NewTimedClient(), fs.URL)
+
+	assert.Equal(t, ConnectionRefusedError, ClassOf(err))
+}
+
+func TestClassifyTLSHandshake(t *testing.T) {
+	fs := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	fs.Config.ErrorLog = log.New(ioutil.Discard, "", 0)
+	fs.StartTLS()
+	defer fs.Close()
+
+	// the default client does not trust the certificate of the test server
+	err := failedTrip(t, NewTimedClient(), fs.URL)
+
+	assert.Equal(t, TLSError, ClassOf(err))
+}
+
+func TestClassifyTimeout(t *testing.T) {
+	fs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+	}))
+	defer fs.Close()
+
+	client := &Client{Client: &http.Client{Timeout: 10 * time.Millisecond}, logger: mutedLogger}
+	err := failedTrip(t, client, fs.URL)
+
+	assert.Equal(t, TimeoutError, ClassOf(err))
+}
+
+func TestClassifyErrors(t *testing.T) {
+	assert.Equal(t, DNSError, ClassOf(&net.DNSError{Err: "no such host", Name: "fake.invalid", IsNotFound: true}))
+	assert.Equal(t, ConnectionResetError, ClassOf(io.ErrUnexpectedEOF))
+	assert.Equal(t, UnknownError, ClassOf(errors.New("boom")))
+	assert.Equal(t, BodyReadError, ClassOf(&TripError{Class: BodyReadError, Err: io.ErrUnexpectedEOF}))
+}
+
+type failingBody struct{}
+
+func (failingBody) Read([]byte) (int, error) { return 0, io.ErrUnexpectedEOF }
+func (failingBody) Close() error             { return nil }
+
+func TestReadAndDiscardFailure(t *testing.T) {
+	resp := &Response{Response: &http.Response{StatusCode: http.StatusOK, Body: failingBody{}}}
+
+	resp.ReadAndDiscard()
+
+	assert.True(t, resp.Failed())
+	assert.Equal(t, BodyReadError, ClassOf(resp.Err))
+}
+
+func TestMetricsCountErrorsByClass(t *testing.T) {
+	m := NewMetrics()
+
+	m.Add(&Response{Err: &TripError{Class: TimeoutError, Err: errors.New("timeout")}})
+	m.Add(&Response{Err: &TripError{Class: TimeoutError, Err: errors.New("timeout")}})
+	m.Add(&Response{Err: &TripError{Class: DNSError, Err: errors.New("dns")}})
+
+	assert.Equal(t, map[ErrorClass]int{TimeoutError: 2, DNSError: 1}, m.Errors)
+	assert.Equal(t, 3, m.ErrorCount())
+	assert.Equal(t, uint64(0), m.Latencies.Count())
+}
@@ -1,16 +1,23 @@
 package scurl
 
 import (
+	"crypto/tls"
+	"fmt"
+	"io"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 var DefaultRate = &Rate{Freq: 50, Per: 1 * time.Second}
 
+// DefaultMaxWorkers bounds the workers of an attack at MaxRate unless MaxWorkersOpt sets a ceiling.
+const DefaultMaxWorkers = 64
+
 func NewConcurrentClient(opts ...func(*ConcurrentClient)) *ConcurrentClient {
 	client := &ConcurrentClient{
-		httpClient: NewTimedClient(),
-		stopper:    NewStopper(),
+		transport: DefaultTransportConfig,
+		stopper:   NewStopper(),
 	}
 
 	for _, opt := range opts {
@@ -30,13 +37,30 @@ func FanOutOpt(num int) func(*ConcurrentClient) {
 	}
 }
 
+// RateOpt paces the hits of every attacker at a constant rate, DefaultRate if rate is nil. A zero rate sends
+// them as fast as possible, see MaxRate.
 func RateOpt(rate *Rate) func(*ConcurrentClient) {
 	return func(client *ConcurrentClient) {
-		if rate == nil || rate.IsZero() {
+		if rate == nil {
 			rate = DefaultRate
 		}
+		if rate.IsZero() {
+			client.pacer = MaxRate
+			return
+		}
 
-		client.rate = rate
+		client.pacer = rate
+	}
+}
+
+// PacerOpt paces the hits of every attacker with the given Pacer instead of a constant Rate.
+func PacerOpt(pacer Pacer) func(*ConcurrentClient) {
+	return func(client *ConcurrentClient) {
+		if pacer == nil {
+			pacer = DefaultRate
+		}
+
+		client.pacer = pacer
 	}
 }
 
@@ -46,63 +70,282 @@ func DurationOpt(du time.Duration) func(*ConcurrentClient) {
 	}
 }
 
+// WorkersOpt starts the given number of workers upfront, split among the fan-out clients. Further workers are
+// spawned on demand once all of them are busy, up to the ceiling of MaxWorkersOpt.
+func WorkersOpt(num int) func(*ConcurrentClient) {
+	return func(client *ConcurrentClient) {
+		if num < 0 {
+			num = 0
+		}
+
+		client.workers = num
+	}
+}
+
+// MaxWorkersOpt limits the number of workers sending requests, split among the fan-out clients. Once all of
+// them are busy hits are delayed until a worker is free and are counted as late, see Metrics.Late. 0 means
+// unlimited for paced attacks, attacks at MaxRate are limited to DefaultMaxWorkers instead.
+func MaxWorkersOpt(num int) func(*ConcurrentClient) {
+	return func(client *ConcurrentClient) {
+		if num < 0 {
+			num = 0
+		}
+
+		client.maxWorkers = num
+	}
+}
+
+// MaxErrorsOpt stops the attack once the given number of trips failed, 0 means the attack never stops because
+// of failures.
+func MaxErrorsOpt(num int) func(*ConcurrentClient) {
+	return func(client *ConcurrentClient) {
+		if num < 0 {
+			num = 0
+		}
+
+		client.maxErrors = num
+	}
+}
+
+// TimeoutOpt limits the duration of every trip, including reading the response body. 0 means no timeout.
+func TimeoutOpt(timeout time.Duration) func(*ConcurrentClient) {
+	return func(client *ConcurrentClient) {
+		client.transport.Timeout = timeout
+	}
+}
+
+func DialTimeoutOpt(timeout time.Duration) func(*ConcurrentClient) {
+	return func(client *ConcurrentClient) {
+		client.transport.DialTimeout = timeout
+	}
+}
+
+func TLSHandshakeTimeoutOpt(timeout time.Duration) func(*ConcurrentClient) {
+	return func(client *ConcurrentClient) {
+		client.transport.TLSHandshakeTimeout = timeout
+	}
+}
+
+// KeepAliveOpt sets the TCP keep-alive period of open connections.
+func KeepAliveOpt(period time.Duration) func(*ConcurrentClient) {
+	return func(client *ConcurrentClient) {
+		client.transport.KeepAlive = period
+	}
+}
+
+// DisableKeepAliveOpt makes every request open a new connection, modeling clients that do not reuse
+// connections.
+func DisableKeepAliveOpt(disable bool) func(*ConcurrentClient) {
+	return func(client *ConcurrentClient) {
+		client.transport.DisableKeepAlive = disable
+	}
+}
+
+// MaxIdleConnsOpt sets the number of idle connections kept open per host for reuse.
+func MaxIdleConnsOpt(num int) func(*ConcurrentClient) {
+	return func(client *ConcurrentClient) {
+		if num < 0 {
+			num = 0
+		}
+
+		client.transport.MaxIdleConns = num
+	}
+}
+
+// MaxConnsPerHostOpt limits the number of connections per host, 0 means unlimited.
+func MaxConnsPerHostOpt(num int) func(*ConcurrentClient) {
+	return func(client *ConcurrentClient) {
+		if num < 0 {
+			num = 0
+		}
+
+		client.transport.MaxConnsPerHost = num
+	}
+}
+
+// ProtocolOpt forces the HTTP protocol of the clients instead of negotiating it.
+func ProtocolOpt(protocol Protocol) func(*ConcurrentClient) {
+	return func(client *ConcurrentClient) {
+		client.transport.Protocol = protocol
+	}
+}
+
+// TLSConfigOpt sets the TLS configuration of the clients, such as the root CAs trusted or client certificates.
+func TLSConfigOpt(config *tls.Config) func(*ConcurrentClient) {
+	return func(client *ConcurrentClient) {
+		client.transport.TLSConfig = config
+	}
+}
+
 func VerboseOpt(verbose bool) func(*ConcurrentClient) {
 	return func(client *ConcurrentClient) {
-		l := &logger{verbose: verbose}
-		client.logger = l
-		client.httpClient.logger = l
+		client.logger = &logger{verbose: verbose}
 	}
 }
 
 type ConcurrentClient struct {
 	logger     *logger
 	fanOut     int
-	rate       *Rate
+	pacer      Pacer
 	du         time.Duration
+	maxErrors  int
+	workers    int
+	maxWorkers int
+	cookies    CookieMode
+	users      int
+	think      ThinkTime
+	transport  TransportConfig
 	httpClient *Client
 	attackers  []attacker
 	stopper    *stopper
+	began      time.Time
+	inFlight   int64
+	active     int64
 }
 
 func (c *ConcurrentClient) Stop() {
 	c.stopper.Stop()
 }
 
-func (c *ConcurrentClient) DoReq(t *Target) <-chan *Response {
-	if c.rate == nil {
-		c.rate = DefaultRate
+// Close closes the connections the clients keep open once the attack is over, the attack cannot be run again.
+func (c *ConcurrentClient) Close() error {
+	if c.httpClient == nil || c.httpClient.Client == nil {
+		return nil
+	}
+
+	c.httpClient.CloseIdleConnections()
+	if closer, ok := c.httpClient.Transport.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// Err returns the error which stopped the attack before its end, such as a request which could not be built.
+func (c *ConcurrentClient) Err() error {
+	return c.stopper.Err()
+}
+
+// InFlight returns the number of requests sent which did not receive a response yet.
+func (c *ConcurrentClient) InFlight() int64 {
+	return atomic.LoadInt64(&c.inFlight)
+}
+
+// Workers returns the number of workers currently running across all attackers.
+func (c *ConcurrentClient) Workers() int64 {
+	return atomic.LoadInt64(&c.active)
+}
+
+// Users returns the number of virtual users of a closed loop, 0 if the attack runs at a rate.
+func (c *ConcurrentClient) Users() int {
+	return c.users
+}
+
+// Unpaced reports whether the attack sends its requests as fast as possible at MaxRate.
+func (c *ConcurrentClient) Unpaced() bool {
+	return c.users == 0 && c.pacer == MaxRate
+}
+
+// HitsPerSecond returns the rate the attack is expected to run at by now, summed over all attackers. A closed
+// loop has no expected rate, its throughput is a result of the attack.
+func (c *ConcurrentClient) HitsPerSecond() float64 {
+	if c.began.IsZero() || c.users > 0 {
+		return 0
+	}
+
+	return c.pacer.HitsPerSecond(time.Since(c.began)) * float64(c.fanOut)
+}
+
+// DoReq attacks the targets provided by t. A single *Target is a Targeter hitting that target only.
+func (c *ConcurrentClient) DoReq(t Targeter) <-chan *Response {
+	return c.attack(t, nil)
+}
+
+// DoScenario plays the scenario on every hit, sending a response for every step which was run. The responses
+// of a step are those whose Target is named after it.
+func (c *ConcurrentClient) DoScenario(s *Scenario) <-chan *Response {
+	return c.attack(nil, s)
+}
+
+func (c *ConcurrentClient) attack(t Targeter, s *Scenario) <-chan *Response {
+	if c.pacer == nil {
+		c.pacer = DefaultRate
 	}
 	if c.logger == nil {
 		c.logger = mutedLogger
 	}
+	if c.httpClient == nil {
+		c.httpClient = NewClient(c.transport)
+	}
+	c.httpClient.logger = c.logger
+	if c.cookies == SharedCookies && c.httpClient.Jar == nil {
+		c.httpClient = c.httpClient.withJar(newCookieJar())
+	}
 
 	workers := sync.WaitGroup{}
 	respCh := make(chan *Response)
+	budget := &errorBudget{max: int64(c.maxErrors)}
 
 	c.logger.debug("duration:", c.du)
-	c.logger.debug("rate:", c.rate)
+	if c.users > 0 {
+		c.logger.debug("users:", c.users)
+		c.logger.debug("think:", c.think)
+	} else {
+		c.logger.debug("pacer:", c.pacer)
+	}
 	c.logger.debug("fanOut:", c.fanOut)
-	c.logger.debug(">", t.Method, t.URL)
-	if len(t.Header) != 0 {
-		c.logger.debug(">")
-		for k, v := range t.Header {
-			c.logger.debug("> ", k, ":", v)
+	c.logger.debug("maxErrors:", c.maxErrors)
+	c.logger.debug("workers:", c.workers)
+	c.logger.debug("maxWorkers:", c.maxWorkers)
+	c.logger.debug("cookies:", c.cookies)
+	c.logger.debug("transport:", fmt.Sprintf("%+v", c.transport))
+	if target, ok := t.(*Target); ok {
+		c.logger.debug(">", target.Method, target.URL)
+		if len(target.Header) != 0 {
+			c.logger.debug(">")
+			for k, v := range target.Header {
+				c.logger.debug("> ", k, ":", v)
+			}
 		}
+		if target.Body != nil {
+			c.logger.debug(">")
+			c.logger.debug(target.Body)
+		}
+	}
+	if s != nil {
+		for _, step := range s.Steps {
+			c.logger.debug(">", step.Target.Name+":", step.Target.Method, step.Target.URL)
+		}
+	}
+
+	maxWorkers := c.maxWorkers
+	if maxWorkers == 0 && c.pacer == MaxRate {
+		maxWorkers = DefaultMaxWorkers
 	}
-	if t.Body != nil {
-		c.logger.debug(">")
-		c.logger.debug(t.Body)
+	if maxWorkers > 0 && maxWorkers < c.fanOut {
+		maxWorkers = c.fanOut // every attacker needs a worker, 0 would mean unlimited
 	}
 
+	c.began = time.Now()
 	for i := 0; i < c.fanOut; i++ {
-		atk := attacker{stopper: c.stopper, logger: c.logger}
+		atk := attacker{client: c.httpClient, stopper: c.stopper, logger: c.logger, errors: budget, inFlight: &c.inFlight,
+			active: &c.active, scenario: s, cookies: c.cookies, workers: share(c.workers, c.fanOut, i),
+			maxWorkers: share(maxWorkers, c.fanOut, i)}
 		c.attackers = append(c.attackers, atk)
 
 		workers.Add(1)
 
+		users := share(c.users, c.fanOut, i)
+
 		go func() {
 			defer workers.Done()
-			for resp := range atk.Attack(t, c.rate, c.du) {
+
+			var results <-chan *Response
+			if c.users > 0 {
+				results = atk.Loop(t, users, c.think, c.du)
+			} else {
+				results = atk.Attack(t, c.pacer, c.du)
+			}
+			for resp := range results {
 				respCh <- resp
 			}
 		}()
@@ -115,3 +358,12 @@ func (c *ConcurrentClient) DoReq(t *Target) <-chan *Response {
 
 	return respCh
 }
+
+// share returns the part of num the i-th of n attackers takes, the first ones take the remainder.
+func share(num, n, i int) int {
+	part := num / n
+	if i < num%n {
+		part++
+	}
+	return part
+}
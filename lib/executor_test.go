@@ -33,19 +33,27 @@ func TestSingleRequest(t *testing.T) {
 	assert.Equal(t, 1, hits)
 }
 
+// writeMalformedResponse answers with an invalid status line and closes the connection, failing the trip on the
+// client side
+func writeMalformedResponse(w http.ResponseWriter) {
+	conn, _, err := w.(http.Hijacker).Hijack()
+	if err != nil {
+		return
+	}
+
+	conn.Write([]byte("HTTP/1.1 malformed\r\n\r\n"))
+	conn.Close()
+}
+
 func TestReturnFirstResponseIfSecondFails(t *testing.T) {
 	var invocationCounter int32 = 0
 
 	blockingHandler := func(w http.ResponseWriter, r *http.Request) {
-		if invocationCounter == 0 {
-			atomic.AddInt32(&invocationCounter, 1)
+		if atomic.AddInt32(&invocationCounter, 1) == 1 {
 			return
 		}
 
-		// according to http spec there should be a location header for a redirect
-		// this will cause the response to fail
-		w.WriteHeader(http.StatusMovedPermanently)
-		w.Header().Del(`Location`)
+		writeMalformedResponse(w)
 	}
 
 	fs := httptest.NewServer(http.HandlerFunc(blockingHandler))
@@ -53,12 +61,65 @@ func TestReturnFirstResponseIfSecondFails(t *testing.T) {
 
 	req, _ := NewTarget(fs.URL)
 
-	hits := 0
-	for range NewConcurrentClient(FanOutOpt(2)).DoReq(req) {
-		hits++
+	var responses []*Response
+	for resp := range NewConcurrentClient(FanOutOpt(1), MaxErrorsOpt(1), DurationOpt(1*time.Hour)).DoReq(req) {
+		responses = append(responses, resp)
 	}
 
-	assert.Equal(t, 1, hits)
+	assert.Equal(t, 2, len(responses))
+	assert.False(t, responses[0].Failed())
+	assert.True(t, responses[1].Failed())
+}
+
+func TestMaxErrorsCountsFailedBodyReads(t *testing.T) {
+	fs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "10")
+		w.Write([]byte("trunc"))
+	}))
+	defer fs.Close()
+
+	req, _ := NewTarget(fs.URL)
+	client := NewConcurrentClient(FanOutOpt(1), MaxErrorsOpt(3), RateOpt(&Rate{Freq: 100, Per: time.Second}),
+		DurationOpt(time.Second))
+
+	failed := 0
+	for resp := range client.DoReq(req) {
+		assert.Equal(t, BodyReadError, ClassOf(resp.Err))
+		failed++
+	}
+
+	// reads in flight may fail before the attack stopped, 100 would mean it never did
+	assert.True(t, failed >= 3 && failed < 10, failed)
+}
+
+func TestRecordFailedRequestsWithoutStopping(t *testing.T) {
+	var invocationCounter int32 = 0
+
+	failingHandler := func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&invocationCounter, 1)%2 == 0 {
+			writeMalformedResponse(w)
+		}
+	}
+
+	fs := httptest.NewServer(http.HandlerFunc(failingHandler))
+	defer fs.Close()
+
+	req, _ := NewTarget(fs.URL)
+
+	client := NewConcurrentClient(
+		FanOutOpt(1),
+		RateOpt(&Rate{Freq: 100, Per: 1 * time.Second}),
+		DurationOpt(100*time.Millisecond),
+	)
+
+	m := NewMetrics()
+	for resp := range client.DoReq(req) {
+		m.Add(resp)
+	}
+
+	assert.Equal(t, 10, m.Trips)
+	assert.Equal(t, 5, m.ErrorCount())
+	assert.Equal(t, 5, m.StatusCodes[http.StatusOK])
 }
 
 func TestCancelAllRequestsIfOrdered(t *testing.T) {
@@ -80,3 +141,138 @@ func TestCancelAllRequestsIfOrdered(t *testing.T) {
 	assert.Nil(t, resp)
 	assert.False(t, ok)
 }
+
+func TestTrackRequestsInFlight(t *testing.T) {
+	release := make(chan struct{})
+	fs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer fs.Close()
+
+	req, _ := NewTarget(fs.URL)
+
+	client := NewConcurrentClient(
+		FanOutOpt(2),
+		RateOpt(&Rate{Freq: 20, Per: time.Second}),
+		DurationOpt(100*time.Millisecond),
+	)
+	assert.Equal(t, 0.0, client.HitsPerSecond())
+
+	res := client.DoReq(req)
+	assert.Equal(t, 40.0, client.HitsPerSecond())
+
+	time.Sleep(200 * time.Millisecond)
+	assert.Equal(t, int64(4), client.InFlight())
+	assert.True(t, client.Workers() >= 4)
+
+	close(release)
+	for range res {
+	}
+	assert.Equal(t, int64(0), client.InFlight())
+	assert.Equal(t, int64(0), client.Workers())
+}
+
+// concurrencyServer responds after the delay and tracks the maximum number of requests it handled at once
+func concurrencyServer(delay time.Duration, maxConcurrent *int64) *httptest.Server {
+	var concurrent int64
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt64(&concurrent, 1)
+		defer atomic.AddInt64(&concurrent, -1)
+		for {
+			max := atomic.LoadInt64(maxConcurrent)
+			if n <= max || atomic.CompareAndSwapInt64(maxConcurrent, max, n) {
+				break
+			}
+		}
+		time.Sleep(delay)
+	}))
+}
+
+func TestAttackAtMaxRateWithinWorkerCeiling(t *testing.T) {
+	var maxConcurrent int64
+	fs := concurrencyServer(10*time.Millisecond, &maxConcurrent)
+	defer fs.Close()
+
+	req, _ := NewTarget(fs.URL)
+	client := NewConcurrentClient(
+		FanOutOpt(2),
+		RateOpt(&Rate{}),
+		MaxWorkersOpt(5),
+		DurationOpt(300*time.Millisecond),
+	)
+
+	trips := 0
+	for r := range client.DoReq(req) {
+		assert.Nil(t, r.Err)
+		trips++
+	}
+
+	assert.Equal(t, int64(5), atomic.LoadInt64(&maxConcurrent))
+	assert.True(t, trips > 60, trips)
+	assert.Equal(t, 0.0, client.HitsPerSecond())
+}
+
+func TestLimitWorkersOfPacedAttack(t *testing.T) {
+	var maxConcurrent int64
+	fs := concurrencyServer(50*time.Millisecond, &maxConcurrent)
+	defer fs.Close()
+
+	req, _ := NewTarget(fs.URL)
+	client := NewConcurrentClient(
+		FanOutOpt(1),
+		RateOpt(&Rate{Freq: 200, Per: time.Second}),
+		MaxWorkersOpt(2),
+		DurationOpt(200*time.Millisecond),
+	)
+
+	for range client.DoReq(req) {
+	}
+
+	assert.Equal(t, int64(2), atomic.LoadInt64(&maxConcurrent))
+}
+
+func TestCountLateHitsAtWorkerCeiling(t *testing.T) {
+	var maxConcurrent int64
+	fs := concurrencyServer(50*time.Millisecond, &maxConcurrent)
+	defer fs.Close()
+
+	req, _ := NewTarget(fs.URL)
+	for maxWorkers, late := range map[int]bool{0: false, 1: true} {
+		client := NewConcurrentClient(
+			FanOutOpt(1),
+			RateOpt(&Rate{Freq: 100, Per: time.Second}),
+			MaxWorkersOpt(maxWorkers),
+			DurationOpt(200*time.Millisecond),
+		)
+
+		metrics := NewMetrics()
+		for r := range client.DoReq(req) {
+			metrics.Add(r)
+		}
+
+		assert.Equal(t, late, metrics.Late > 0, maxWorkers)
+		assert.True(t, metrics.Late < metrics.Trips, maxWorkers)
+	}
+}
+
+func TestStartWorkersUpfront(t *testing.T) {
+	fs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer fs.Close()
+
+	req, _ := NewTarget(fs.URL)
+	client := NewConcurrentClient(
+		FanOutOpt(2),
+		RateOpt(&Rate{Freq: 10, Per: time.Second}),
+		WorkersOpt(5),
+		MaxWorkersOpt(4),
+		DurationOpt(300*time.Millisecond),
+	)
+
+	res := client.DoReq(req)
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, int64(4), client.Workers())
+
+	for range res {
+	}
+	assert.Equal(t, int64(0), client.Workers())
+}
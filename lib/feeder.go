@@ -0,0 +1,193 @@
+package scurl
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Row is a record of a dataset, mapping the names of its columns to their values.
+type Row map[string]string
+
+// Feeder provides the rows of a dataset the requests of an attack are rendered with. Implementations must be
+// safe for concurrent use. Next returns io.EOF once the attack should end because the data ran out, any other
+// error fails the attack.
+type Feeder interface {
+	Next() (Row, error)
+}
+
+// FeedOrder is the order in which a feeder hands out its rows.
+type FeedOrder string
+
+const (
+	SequentialFeed FeedOrder = "sequential" // rows in the order of the dataset
+	RandomFeed     FeedOrder = "random"     // rows in a random order, each row is used once before any is reused
+	CircularFeed   FeedOrder = "circular"   // rows in the order of the dataset, starting over once they ran out
+)
+
+// FeedExhaustion is what happens once a feeder ran out of rows.
+type FeedExhaustion string
+
+const (
+	StopOnExhaustion  FeedExhaustion = "stop"  // the attack ends
+	WrapOnExhaustion  FeedExhaustion = "wrap"  // the rows are handed out again
+	ErrorOnExhaustion FeedExhaustion = "error" // the attack fails with ErrFeederExhausted
+)
+
+var ErrFeederExhausted = errors.New("feeder ran out of rows")
+
+type rowFeeder struct {
+	rows       []Row
+	order      FeedOrder
+	exhaustion FeedExhaustion
+
+	mu   sync.Mutex
+	next int
+	perm []int // order of the rows of a random feeder
+	rnd  *rand.Rand
+}
+
+// NewFeeder hands out the rows in the given order. A circular feeder always wraps around, regardless of the
+// exhaustion behavior.
+func NewFeeder(rows []Row, order FeedOrder, exhaustion FeedExhaustion) Feeder {
+	f := &rowFeeder{rows: rows, order: order, exhaustion: exhaustion}
+	if order == CircularFeed {
+		f.exhaustion = WrapOnExhaustion
+	}
+	if order == RandomFeed {
+		f.rnd = rand.New(rand.NewSource(time.Now().UnixNano()))
+		f.perm = f.rnd.Perm(len(rows))
+	}
+
+	return f
+}
+
+func (f *rowFeeder) Next() (Row, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if len(f.rows) == 0 {
+		return nil, ErrFeederExhausted
+	}
+
+	if f.next == len(f.rows) {
+		switch f.exhaustion {
+		case WrapOnExhaustion:
+			f.next = 0
+			if f.perm != nil {
+				f.perm = f.rnd.Perm(len(f.rows))
+			}
+		case ErrorOnExhaustion:
+			return nil, ErrFeederExhausted
+		default:
+			return nil, io.EOF
+		}
+	}
+
+	i := f.next
+	f.next++
+	if f.perm != nil {
+		i = f.perm[i]
+	}
+
+	return f.rows[i], nil
+}
+
+// ReadCSVRows reads a dataset in the CSV format, its first row names the columns.
+func ReadCSVRows(r io.Reader) ([]Row, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("missing header row")
+	}
+
+	columns := records[0]
+	for i := range columns {
+		columns[i] = strings.TrimSpace(columns[i])
+	}
+
+	rows := make([]Row, 0, len(records)-1)
+	for _, record := range records[1:] {
+		row := make(Row, len(columns))
+		for i, column := range columns {
+			row[column] = record[i]
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// ReadJSONRows reads a dataset in the JSON-lines format, one object per line. Values which are not strings are
+// kept in their JSON encoding.
+//
+//	{"id": 1, "name": "ann"}
+func ReadJSONRows(r io.Reader) ([]Row, error) {
+	var rows []Row
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+
+		var object map[string]json.RawMessage
+		if err := json.Unmarshal(scanner.Bytes(), &object); err != nil {
+			return nil, fmt.Errorf("line %d: %s", line, err)
+		}
+
+		row := make(Row, len(object))
+		for column, raw := range object {
+			var s string
+			if err := json.Unmarshal(raw, &s); err == nil {
+				row[column] = s
+			} else {
+				row[column] = string(raw)
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return rows, nil
+}
+
+type feedTargeter struct {
+	targeter Targeter
+	feeder   Feeder
+}
+
+// NewFeedTargeter renders the targets of t with the next row of the feeder on every hit. Targets have to be
+// templated with TemplateOption, their templates refer to the columns of a row as {{.column}}.
+func NewFeedTargeter(t Targeter, f Feeder) Targeter {
+	return &feedTargeter{targeter: t, feeder: f}
+}
+
+func (t *feedTargeter) Next() (*Target, error) {
+	target, err := t.targeter.Next()
+	if err != nil {
+		return nil, err
+	}
+
+	row, err := t.feeder.Next()
+	if err != nil {
+		return nil, err
+	}
+
+	fed := *target
+	fed.row = row
+	return &fed, nil
+}
@@ -0,0 +1,133 @@
+package scurl
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func feedRows(n int) []Row {
+	rows := make([]Row, n)
+	for i := range rows {
+		rows[i] = Row{"id": string(rune('a' + i))}
+	}
+	return rows
+}
+
+func feedIDs(t *testing.T, f Feeder, n int) []string {
+	ids := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		row, err := f.Next()
+		assert.Nil(t, err)
+		ids = append(ids, row["id"])
+	}
+	return ids
+}
+
+func TestSequentialFeeder(t *testing.T) {
+	f := NewFeeder(feedRows(3), SequentialFeed, StopOnExhaustion)
+
+	assert.Equal(t, []string{"a", "b", "c"}, feedIDs(t, f, 3))
+	_, err := f.Next()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestFeederExhaustion(t *testing.T) {
+	f := NewFeeder(feedRows(2), SequentialFeed, ErrorOnExhaustion)
+	feedIDs(t, f, 2)
+	_, err := f.Next()
+	assert.Equal(t, ErrFeederExhausted, err)
+
+	f = NewFeeder(feedRows(2), SequentialFeed, WrapOnExhaustion)
+	assert.Equal(t, []string{"a", "b", "a", "b", "a"}, feedIDs(t, f, 5))
+
+	f = NewFeeder(feedRows(2), CircularFeed, StopOnExhaustion)
+	assert.Equal(t, []string{"a", "b", "a"}, feedIDs(t, f, 3))
+
+	_, err = NewFeeder(nil, CircularFeed, WrapOnExhaustion).Next()
+	assert.Equal(t, ErrFeederExhausted, err)
+}
+
+func TestRandomFeederUsesEveryRowOnce(t *testing.T) {
+	f := NewFeeder(feedRows(5), RandomFeed, WrapOnExhaustion)
+
+	for round := 0; round < 3; round++ {
+		ids := feedIDs(t, f, 5)
+		sort.Strings(ids)
+		assert.Equal(t, []string{"a", "b", "c", "d", "e"}, ids)
+	}
+}
+
+func TestReadRows(t *testing.T) {
+	rows, err := ReadCSVRows(strings.NewReader("id, name\n1,ann\n2,bob\n"))
+	assert.Nil(t, err)
+	assert.Equal(t, []Row{{"id": "1", "name": "ann"}, {"id": "2", "name": "bob"}}, rows)
+
+	rows, err = ReadJSONRows(strings.NewReader(`{"id": 1, "name": "ann", "tags": ["a"]}` + "\n\n" + `{"id": 2, "name": "bob"}`))
+	assert.Nil(t, err)
+	assert.Equal(t, []Row{{"id": "1", "name": "ann", "tags": `["a"]`}, {"id": "2", "name": "bob"}}, rows)
+
+	_, err = ReadCSVRows(strings.NewReader(""))
+	assert.NotNil(t, err)
+	_, err = ReadJSONRows(strings.NewReader("[1]"))
+	assert.NotNil(t, err)
+}
+
+func TestFeedTargets(t *testing.T) {
+	target, err := NewTarget("http://localhost/users/{{.id}}", HeaderOption("X-Name: {{.name}}"), TemplateOption())
+	assert.Nil(t, err)
+
+	tr := NewFeedTargeter(target, NewFeeder([]Row{{"id": "1", "name": "ann"}, {"id": "2", "name": "bob"}}, SequentialFeed, StopOnExhaustion))
+
+	for _, expected := range []Row{{"id": "1", "name": "ann"}, {"id": "2", "name": "bob"}} {
+		fed, err := tr.Next()
+		assert.Nil(t, err)
+
+		req, err := fed.RequestWithContext(context.Background())
+		assert.Nil(t, err)
+		assert.Equal(t, "/users/"+expected["id"], req.URL.Path)
+		assert.Equal(t, expected["name"], req.Header.Get("X-Name"))
+	}
+
+	_, err = tr.Next()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestFailRequestsWithMissingColumn(t *testing.T) {
+	target, _ := NewTarget("http://localhost/users/{{.missing}}", TemplateOption())
+	fed, _ := NewFeedTargeter(target, NewFeeder([]Row{{"id": "1"}}, SequentialFeed, StopOnExhaustion)).Next()
+
+	_, err := fed.RequestWithContext(context.Background())
+	assert.NotNil(t, err)
+
+	_, err = target.RequestWithContext(context.Background())
+	assert.NotNil(t, err)
+}
+
+func TestAttackEndsWhenFeederRunsOut(t *testing.T) {
+	fs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer fs.Close()
+
+	for exhaustion, expected := range map[FeedExhaustion]error{StopOnExhaustion: nil, ErrorOnExhaustion: ErrFeederExhausted} {
+		target, _ := NewTarget(fs.URL+"/{{.id}}", TemplateOption())
+		client := NewConcurrentClient(
+			FanOutOpt(1),
+			RateOpt(&Rate{Freq: 100, Per: time.Second}),
+			DurationOpt(5*time.Second),
+		)
+
+		hits := 0
+		for range client.DoReq(NewFeedTargeter(target, NewFeeder(feedRows(3), SequentialFeed, exhaustion))) {
+			hits++
+		}
+
+		assert.Equal(t, 3, hits, string(exhaustion))
+		assert.Equal(t, expected, client.Err(), string(exhaustion))
+	}
+}
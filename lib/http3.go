@@ -0,0 +1,19 @@
+package scurl
+
+import (
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+)
+
+// http3Transport sends the requests over QUIC. All requests to a host are multiplexed over a single connection,
+// the settings of TCP connections do not apply except for the handshake timeout and the keep-alive period: the
+// dial timeout, disabled keep-alives and the limits of idle and open connections are ignored.
+func (cfg TransportConfig) http3Transport() *http3.Transport {
+	return &http3.Transport{
+		TLSClientConfig: cfg.TLSConfig.Clone(),
+		QUICConfig: &quic.Config{
+			HandshakeIdleTimeout: cfg.TLSHandshakeTimeout,
+			KeepAlivePeriod:      cfg.KeepAlive,
+		},
+	}
+}
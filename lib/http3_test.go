@@ -0,0 +1,106 @@
+package scurl
+
+import (
+	"crypto/tls"
+	"github.com/quic-go/quic-go/http3"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// http3Server serves the handler over HTTP/3 on a local UDP port with the certificate of a TLS test server, it
+// returns the URL of the server and a client TLS config trusting the certificate.
+func http3Server(t *testing.T, handler http.Handler) (string, *tls.Config, func()) {
+	certs := httptest.NewTLSServer(handler)
+	certs.Close()
+
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	assert.Nil(t, err)
+
+	server := &http3.Server{Handler: handler, TLSConfig: http3.ConfigureTLSConfig(&tls.Config{Certificates: certs.TLS.Certificates})}
+	go server.Serve(conn)
+
+	return "https://" + conn.LocalAddr().String(), trustingConfig(certs), func() {
+		server.Close()
+		conn.Close()
+	}
+}
+
+func TestHTTP3Client(t *testing.T) {
+	url, tlsConfig, stop := http3Server(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer stop()
+
+	attack := func(opts ...func(*ConcurrentClient)) *Metrics {
+		client := NewConcurrentClient(append(opts,
+			ProtocolOpt(HTTP3),
+			FanOutOpt(1),
+			RateOpt(&Rate{Freq: 20, Per: time.Second}),
+			DurationOpt(100*time.Millisecond),
+		)...)
+		defer client.Close()
+
+		return attackSequentially(client, url)
+	}
+
+	trusted := attack(TLSConfigOpt(tlsConfig))
+	assert.Equal(t, map[string]int{"HTTP/3.0": 2}, trusted.Protocols)
+	assert.Equal(t, 0, trusted.ErrorCount())
+
+	untrusted := attack()
+	assert.Equal(t, 2, untrusted.Trips)
+	assert.Equal(t, map[ErrorClass]int{TLSError: 2}, untrusted.Errors)
+}
+
+func TestAttackOverHTTP3(t *testing.T) {
+	url, tlsConfig, stop := http3Server(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Proto))
+	}))
+	defer stop()
+
+	client := NewConcurrentClient(
+		ProtocolOpt(HTTP3),
+		TLSConfigOpt(tlsConfig),
+		FanOutOpt(1),
+		RateOpt(&Rate{Freq: 20, Per: time.Second}),
+		DurationOpt(250*time.Millisecond),
+	)
+	defer client.Close()
+	target, _ := NewTarget(url, ExpectOption(ExpectBodyContains("HTTP/3")))
+
+	m := NewMetrics()
+	handshakes := 0
+	for r := range client.DoReq(target) {
+		assert.Nil(t, r.Err)
+		assert.Nil(t, r.Invalid)
+		if r.Handshake > 0 {
+			handshakes++
+		}
+		r.ReadAndDiscard()
+		m.Add(r)
+	}
+
+	assert.Equal(t, 5, m.Trips)
+	assert.Equal(t, map[string]int{"HTTP/3.0": 5}, m.Protocols)
+	assert.Equal(t, 1, handshakes)
+	assert.Equal(t, uint64(1), m.Handshakes.Count())
+}
+
+func TestHTTP3RequiresHTTPS(t *testing.T) {
+	url, tlsConfig, stop := http3Server(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer stop()
+
+	client := NewConcurrentClient(
+		ProtocolOpt(HTTP3),
+		TLSConfigOpt(tlsConfig),
+		FanOutOpt(1),
+		RateOpt(&Rate{Freq: 20, Per: time.Second}),
+		DurationOpt(100*time.Millisecond),
+	)
+	m := attackSequentially(client, strings.Replace(url, "https", "http", 1))
+
+	assert.Equal(t, map[ErrorClass]int{ProtocolError: 2}, m.Errors)
+}
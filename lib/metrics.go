@@ -0,0 +1,199 @@
+package scurl
+
+import (
+	"fmt"
+	"time"
+)
+
+// Metrics aggregates responses in constant memory. Unlike MultiResponse it does not retain the responses it
+// consumes, only counters and latency sketches, which makes it suitable for long running attacks.
+type Metrics struct {
+	Trips         int
+	StartTime     time.Time
+	EndTime       time.Time // when the last response was received
+	Bytes         uint64
+	StatusCodes   map[int]int
+	Protocols     map[string]int // responses by the protocol they were received over, i.e. HTTP/2.0
+	Errors        map[ErrorClass]int
+	Invalid       map[string]int      // responses which violated a rule of their target, by the rule
+	Late          int                 // trips which waited for a busy worker, see MaxWorkersOpt
+	Latencies     Sketch              // service times
+	ResponseTimes Sketch              // response times corrected for coordinated omission
+	Handshakes    Sketch              // durations of the TLS or QUIC handshakes of the connections opened
+	Steps         map[string]*Metrics // the trips of every step of a scenario by its name, nil outside of scenarios
+}
+
+func NewMetrics() *Metrics {
+	return &Metrics{StartTime: time.Now(), StatusCodes: map[int]int{}, Protocols: map[string]int{}, Errors: map[ErrorClass]int{}, Invalid: map[string]int{}}
+}
+
+// Add records a trip. Failed trips are counted by their ErrorClass and are not part of the latency statistics.
+func (m *Metrics) Add(r *Response) {
+	if r.Target != nil && r.Target.Name != "" {
+		m.step(r.Target.Name).add(r)
+	}
+	m.add(r)
+}
+
+func (m *Metrics) add(r *Response) {
+	m.init()
+
+	m.Trips++
+	if r.Late {
+		m.Late++
+	}
+	if !r.Sent.IsZero() {
+		if m.StartTime.IsZero() || r.Sent.Before(m.StartTime) {
+			m.StartTime = r.Sent
+		}
+		if end := r.Sent.Add(r.Time); end.After(m.EndTime) {
+			m.EndTime = end
+		}
+	}
+
+	if r.Failed() {
+		m.Errors[ClassOf(r.Err)]++
+		return
+	}
+
+	m.Bytes += uint64(r.TotalBytes)
+	m.Latencies.Add(r.Time)
+	m.ResponseTimes.Add(r.ResponseTime())
+	if r.Handshake > 0 {
+		m.Handshakes.Add(r.Handshake)
+	}
+	if r.Response != nil {
+		m.StatusCodes[r.StatusCode]++
+		if r.Proto != "" {
+			m.Protocols[r.Proto]++
+		}
+	}
+	if r.Invalid != nil {
+		m.Invalid[r.Invalid.Rule.String()]++
+	}
+}
+
+func (m *Metrics) step(name string) *Metrics {
+	if m.Steps == nil {
+		m.Steps = map[string]*Metrics{}
+	}
+	step, ok := m.Steps[name]
+	if !ok {
+		step = &Metrics{StatusCodes: map[int]int{}, Protocols: map[string]int{}, Errors: map[ErrorClass]int{}, Invalid: map[string]int{}}
+		m.Steps[name] = step
+	}
+
+	return step
+}
+
+func (m *Metrics) init() {
+	if m.StatusCodes == nil {
+		m.StatusCodes = map[int]int{}
+	}
+	if m.Protocols == nil {
+		m.Protocols = map[string]int{}
+	}
+	if m.Errors == nil {
+		m.Errors = map[ErrorClass]int{}
+	}
+	if m.Invalid == nil {
+		m.Invalid = map[string]int{}
+	}
+}
+
+// Merge adds the metrics aggregated by other to m.
+func (m *Metrics) Merge(other *Metrics) {
+	m.init()
+	if m.StartTime.IsZero() || (!other.StartTime.IsZero() && other.StartTime.Before(m.StartTime)) {
+		m.StartTime = other.StartTime
+	}
+	if other.EndTime.After(m.EndTime) {
+		m.EndTime = other.EndTime
+	}
+
+	m.Trips += other.Trips
+	m.Late += other.Late
+	m.Bytes += other.Bytes
+	m.Latencies.Merge(&other.Latencies)
+	m.ResponseTimes.Merge(&other.ResponseTimes)
+	m.Handshakes.Merge(&other.Handshakes)
+	for code, count := range other.StatusCodes {
+		m.StatusCodes[code] += count
+	}
+	for proto, count := range other.Protocols {
+		m.Protocols[proto] += count
+	}
+	for class, count := range other.Errors {
+		m.Errors[class] += count
+	}
+	for rule, count := range other.Invalid {
+		m.Invalid[rule] += count
+	}
+	for name, step := range other.Steps {
+		m.step(name).Merge(step)
+	}
+}
+
+func (m *Metrics) Empty() bool {
+	return m.Trips == 0
+}
+
+// ErrorCount returns the number of failed trips.
+func (m *Metrics) ErrorCount() int {
+	count := 0
+	for _, c := range m.Errors {
+		count += c
+	}
+	return count
+}
+
+// InvalidCount returns the number of responses which failed validation.
+func (m *Metrics) InvalidCount() int {
+	count := 0
+	for _, c := range m.Invalid {
+		count += c
+	}
+	return count
+}
+
+// TotalTime returns the time from the start until the last response was received, or until now if no
+// response was received yet.
+func (m *Metrics) TotalTime() time.Duration {
+	if m.EndTime.IsZero() {
+		return time.Since(m.StartTime)
+	}
+
+	return m.EndTime.Sub(m.StartTime)
+}
+
+func (m *Metrics) TotalBytes() uint64 {
+	return m.Bytes
+}
+
+func (m *Metrics) AvrTime() time.Duration {
+	return m.Latencies.Mean()
+}
+
+func (m *Metrics) Fastest() time.Duration {
+	return m.Latencies.Min()
+}
+
+func (m *Metrics) Slowest() time.Duration {
+	return m.Latencies.Max()
+}
+
+func (m *Metrics) Percentiles(ps ...float64) []time.Duration {
+	return m.Latencies.Percentiles(ps...)
+}
+
+func (m *Metrics) Percentile(p float64) time.Duration {
+	return m.Latencies.Percentile(p)
+}
+
+func (m *Metrics) Histogram(bounds ...time.Duration) []Bucket {
+	return m.Latencies.Histogram(bounds...)
+}
+
+func (m *Metrics) String() string {
+	return fmt.Sprintf("{time=%s, trips=%d, bytes=%d, codes=%v, errors=%v}", m.TotalTime(), m.Trips, m.Bytes, m.StatusCodes, m.Errors)
+}
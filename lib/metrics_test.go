@@ -0,0 +1,63 @@
+package scurl
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestMetricsAdd(t *testing.T) {
+	m := NewMetrics()
+
+	m.Add(&Response{Response: &http.Response{StatusCode: http.StatusOK}, Time: time.Millisecond, TotalBytes: 10})
+	m.Add(&Response{Response: &http.Response{StatusCode: http.StatusOK}, Time: 3 * time.Millisecond, TotalBytes: 20})
+	m.Add(&Response{Response: &http.Response{StatusCode: http.StatusBadRequest}, Time: 2 * time.Millisecond})
+
+	assert.Equal(t, 3, m.Trips)
+	assert.Equal(t, uint64(30), m.TotalBytes())
+	assert.Equal(t, map[int]int{http.StatusOK: 2, http.StatusBadRequest: 1}, m.StatusCodes)
+	assert.Equal(t, time.Millisecond, m.Fastest())
+	assert.Equal(t, 3*time.Millisecond, m.Slowest())
+	assert.Equal(t, 2*time.Millisecond, m.AvrTime())
+}
+
+func TestMergeMetrics(t *testing.T) {
+	first, second := NewMetrics(), NewMetrics()
+	first.Add(&Response{Response: &http.Response{StatusCode: http.StatusOK, Proto: "HTTP/1.1"}, TotalBytes: 1})
+	second.Add(&Response{Response: &http.Response{StatusCode: http.StatusOK, Proto: "HTTP/2.0"}, TotalBytes: 2})
+
+	first.Merge(second)
+
+	assert.Equal(t, 2, first.Trips)
+	assert.Equal(t, uint64(3), first.Bytes)
+	assert.Equal(t, map[int]int{http.StatusOK: 2}, first.StatusCodes)
+	assert.Equal(t, map[string]int{"HTTP/1.1": 1, "HTTP/2.0": 1}, first.Protocols)
+}
+
+func TestMultiResponseMetrics(t *testing.T) {
+	resp := &MultiResponse{}
+	resp.Add(&Response{Response: &http.Response{StatusCode: http.StatusOK}, Time: time.Millisecond, TotalBytes: 5})
+	resp.Add(&Response{Response: &http.Response{StatusCode: http.StatusOK}, Time: time.Second, TotalBytes: 5})
+
+	m := resp.Metrics()
+
+	assert.Equal(t, resp.Trips, m.Trips)
+	assert.Equal(t, resp.TotalBites(), m.TotalBytes())
+	assert.Equal(t, resp.Slowest().Time, m.Slowest())
+}
+
+func TestMetricsTrackResponseTimes(t *testing.T) {
+	m := NewMetrics()
+	intended := time.Now()
+
+	m.Add(&Response{
+		Response: &http.Response{StatusCode: http.StatusOK},
+		Time:     time.Millisecond,
+		Intended: intended,
+		Sent:     intended.Add(9 * time.Millisecond),
+	})
+
+	assert.Equal(t, time.Millisecond, m.Slowest())
+	assert.Equal(t, 10*time.Millisecond, m.ResponseTimes.Max())
+}
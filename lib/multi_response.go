@@ -2,9 +2,13 @@ package scurl
 
 import (
 	"fmt"
+	"math"
+	"sort"
 	"time"
 )
 
+// MultiResponse retains every response it consumes. It allows exact statistics and access to each individual
+// response, at the cost of memory growing with the number of trips. Use Metrics for long running attacks.
 type MultiResponse struct {
 	Responses []*Response
 	Trips     int
@@ -85,7 +89,9 @@ func (c *MultiResponse) Empty() bool {
 func (c *MultiResponse) Close() {
 	if c.Responses != nil {
 		for _, r := range c.Responses {
-			r.Body.Close()
+			if r.Response != nil && r.Body != nil {
+				r.Body.Close()
+			}
 		}
 	}
 }
@@ -98,6 +104,9 @@ func (c *MultiResponse) StatusMap() map[int][]*Response {
 	statMap := make(map[int][]*Response)
 
 	for _, v := range c.Responses {
+		if v.Response == nil {
+			continue
+		}
 		statMap[v.StatusCode] = append(statMap[v.StatusCode], v)
 	}
 
@@ -116,3 +125,115 @@ func (c *MultiResponse) TotalBites() uint64 {
 
 	return total
 }
+
+// Metrics aggregates the retained responses into Metrics.
+func (c *MultiResponse) Metrics() *Metrics {
+	m := &Metrics{StartTime: c.StartTime}
+	for _, r := range c.Responses {
+		m.Add(r)
+	}
+
+	return m
+}
+
+// DefaultBuckets are the upper bounds of the latency buckets used by Histogram when none are provided.
+var DefaultBuckets = []time.Duration{
+	1 * time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	1 * time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// Bucket counts the responses with latency in the [From, To) range.
+// The last bucket of a histogram is unbounded and has To set to math.MaxInt64.
+type Bucket struct {
+	From  time.Duration
+	To    time.Duration
+	Count int
+}
+
+func (b Bucket) Unbounded() bool {
+	return b.To == math.MaxInt64
+}
+
+func (b Bucket) String() string {
+	if b.Unbounded() {
+		return fmt.Sprintf("[%s, +Inf)", b.From)
+	}
+	return fmt.Sprintf("[%s, %s)", b.From, b.To)
+}
+
+// Percentiles returns the latencies at the requested percentiles (0 < p <= 100) using the nearest-rank method.
+func (c *MultiResponse) Percentiles(ps ...float64) []time.Duration {
+	result := make([]time.Duration, len(ps))
+	if len(c.Responses) == 0 {
+		return result
+	}
+
+	latencies := make([]time.Duration, len(c.Responses))
+	for i, r := range c.Responses {
+		latencies[i] = r.Time
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+
+	for i, p := range ps {
+		result[i] = latencies[rank(p, len(latencies))]
+	}
+
+	return result
+}
+
+func (c *MultiResponse) Percentile(p float64) time.Duration {
+	return c.Percentiles(p)[0]
+}
+
+// Histogram buckets the response latencies using the provided ascending upper bounds, or DefaultBuckets if
+// none are provided. Latencies greater than or equal to the last bound fall in an additional unbounded bucket.
+func (c *MultiResponse) Histogram(bounds ...time.Duration) []Bucket {
+	buckets := newBuckets(bounds)
+
+	for _, r := range c.Responses {
+		buckets[bucketIndex(buckets, r.Time)].Count++
+	}
+
+	return buckets
+}
+
+func newBuckets(bounds []time.Duration) []Bucket {
+	if len(bounds) == 0 {
+		bounds = DefaultBuckets
+	}
+
+	buckets := make([]Bucket, 0, len(bounds)+1)
+	from := time.Duration(0)
+	for _, to := range bounds {
+		buckets = append(buckets, Bucket{From: from, To: to})
+		from = to
+	}
+
+	return append(buckets, Bucket{From: from, To: math.MaxInt64})
+}
+
+func bucketIndex(buckets []Bucket, d time.Duration) int {
+	return sort.Search(len(buckets)-1, func(i int) bool { return d < buckets[i].To })
+}
+
+// rank returns the index of the p-th percentile in a sorted slice of the given size.
+func rank(p float64, size int) int {
+	idx := int(math.Ceil(p/100*float64(size))) - 1
+	if idx < 0 {
+		return 0
+	}
+	if idx >= size {
+		return size - 1
+	}
+	return idx
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"net/http"
 	"testing"
+	"time"
 )
 
 func TestCanCloseEmptyResponse(t *testing.T) {
@@ -18,3 +19,43 @@ func TestAddResponse(t *testing.T) {
 
 	assert.Equal(t, 1, len(resp.Responses))
 }
+
+func TestPercentiles(t *testing.T) {
+	resp := &MultiResponse{}
+	for i := 1; i <= 100; i++ {
+		resp.Add(&Response{Time: time.Duration(i) * time.Millisecond})
+	}
+
+	actual := resp.Percentiles(50, 90, 99, 99.9, 100)
+
+	expected := []time.Duration{
+		50 * time.Millisecond,
+		90 * time.Millisecond,
+		99 * time.Millisecond,
+		100 * time.Millisecond,
+		100 * time.Millisecond,
+	}
+
+	assert.Equal(t, expected, actual)
+}
+
+func TestPercentileOfEmptyResponse(t *testing.T) {
+	resp := &MultiResponse{}
+
+	assert.Equal(t, time.Duration(0), resp.Percentile(99))
+}
+
+func TestHistogram(t *testing.T) {
+	resp := &MultiResponse{}
+	for _, d := range []time.Duration{1, 5, 10, 15, 20, 30} {
+		resp.Add(&Response{Time: d * time.Millisecond})
+	}
+
+	buckets := resp.Histogram(10*time.Millisecond, 20*time.Millisecond)
+
+	assert.Equal(t, 3, len(buckets))
+	assert.Equal(t, Bucket{From: 0, To: 10 * time.Millisecond, Count: 2}, buckets[0])
+	assert.Equal(t, Bucket{From: 10 * time.Millisecond, To: 20 * time.Millisecond, Count: 2}, buckets[1])
+	assert.Equal(t, 2, buckets[2].Count)
+	assert.True(t, buckets[2].Unbounded())
+}
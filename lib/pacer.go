@@ -0,0 +1,294 @@
+package scurl
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Pacer decides when the hits of an attack are due. Rate is the Pacer of a constant rate.
+type Pacer interface {
+	// Pace returns how long to wait before the next hit, given the time elapsed since the attack began and the
+	// number of hits so far. A negative wait means the hit was due that long ago. Pace reports stop once the
+	// pacer has no more hits to schedule.
+	Pace(elapsed time.Duration, hits uint64) (wait time.Duration, stop bool)
+
+	// HitsPerSecond returns the expected rate at the time elapsed since the attack began.
+	HitsPerSecond(elapsed time.Duration) float64
+}
+
+// MaxRate is the Pacer of an unpaced attack, every hit is due as soon as a worker is free to send it. The
+// throughput is bounded by the number of workers, see MaxWorkersOpt.
+var MaxRate Pacer = maxRate{}
+
+type maxRate struct{}
+
+func (maxRate) Pace(time.Duration, uint64) (time.Duration, bool) {
+	return 0, false
+}
+
+// HitsPerSecond is 0, the rate of an unpaced attack is its result.
+func (maxRate) HitsPerSecond(time.Duration) float64 {
+	return 0
+}
+
+func (maxRate) String() string {
+	return "max"
+}
+
+// maxDue is the latest time a hit is ever scheduled at, pacers that do not reach a hit by then stop.
+const maxDue = time.Duration(math.MaxInt64 / 2)
+
+// hitsCurve returns the expected number of hits by the time elapsed since the attack began. It has to be
+// non-decreasing.
+type hitsCurve func(elapsed time.Duration) float64
+
+// pace schedules the next hit of a pacer described by its hits curve.
+func pace(curve hitsCurve, elapsed time.Duration, hits uint64) (time.Duration, bool) {
+	due, ok := dueTime(curve, float64(hits))
+	if !ok {
+		return 0, true
+	}
+
+	return due - elapsed, false
+}
+
+// dueTime searches for the earliest time by which the curve reaches the given number of hits.
+func dueTime(curve hitsCurve, hits float64) (time.Duration, bool) {
+	if curve(0) >= hits {
+		return 0, true
+	}
+
+	lo, hi := time.Duration(0), time.Millisecond
+	for curve(hi) < hits {
+		if hi >= maxDue {
+			return 0, false
+		}
+		lo, hi = hi, hi*2
+	}
+
+	for hi-lo > 1 {
+		mid := lo + (hi-lo)/2
+		if curve(mid) >= hits {
+			hi = mid
+		} else {
+			lo = mid
+		}
+	}
+
+	return hi, true
+}
+
+// LinearPacer changes the rate linearly From the starting rate To the final one Over the given period and keeps
+// the final rate afterwards. Rates are in hits per second.
+type LinearPacer struct {
+	From float64
+	To   float64
+	Over time.Duration
+}
+
+func (p *LinearPacer) Pace(elapsed time.Duration, hits uint64) (time.Duration, bool) {
+	return pace(p.hits, elapsed, hits)
+}
+
+func (p *LinearPacer) HitsPerSecond(elapsed time.Duration) float64 {
+	if elapsed >= p.Over {
+		return p.To
+	}
+
+	return p.From + (p.To-p.From)*elapsed.Seconds()/p.Over.Seconds()
+}
+
+func (p *LinearPacer) hits(elapsed time.Duration) float64 {
+	if elapsed >= p.Over {
+		return rampHits(p.From, p.To, p.Over.Seconds(), p.Over.Seconds()) + p.To*(elapsed-p.Over).Seconds()
+	}
+
+	return rampHits(p.From, p.To, p.Over.Seconds(), elapsed.Seconds())
+}
+
+func (p *LinearPacer) String() string {
+	return fmt.Sprintf("linear %v->%v hits/s over %s", p.From, p.To, p.Over)
+}
+
+// StepPacer starts with the Start rate and increases it by Step Every given period. Rates are in hits per second.
+type StepPacer struct {
+	Start float64
+	Step  float64
+	Every time.Duration
+}
+
+func (p *StepPacer) Pace(elapsed time.Duration, hits uint64) (time.Duration, bool) {
+	return pace(p.hits, elapsed, hits)
+}
+
+func (p *StepPacer) HitsPerSecond(elapsed time.Duration) float64 {
+	return math.Max(0, p.Start+p.Step*math.Floor(float64(elapsed)/float64(p.Every)))
+}
+
+func (p *StepPacer) hits(elapsed time.Duration) float64 {
+	steps := math.Floor(float64(elapsed) / float64(p.Every))
+	every := p.Every.Seconds()
+
+	// only the steps with a positive rate contribute hits
+	positive := steps
+	if p.Step < 0 {
+		positive = math.Max(0, math.Min(steps, math.Ceil(-p.Start/p.Step)))
+	}
+	hits := (positive*p.Start + p.Step*positive*(positive-1)/2) * every
+
+	return hits + p.HitsPerSecond(elapsed)*(elapsed.Seconds()-steps*every)
+}
+
+func (p *StepPacer) String() string {
+	return fmt.Sprintf("step %v%+v hits/s every %s", p.Start, p.Step, p.Every)
+}
+
+// SinePacer oscillates the rate around its Mean by the given Amplitude with the given Period, starting at the
+// mean and rising, or falling for a negative Amplitude. Rates are in hits per second and the magnitude of the
+// Amplitude should not exceed the Mean.
+type SinePacer struct {
+	Mean      float64
+	Amplitude float64
+	Period    time.Duration
+}
+
+func (p *SinePacer) Pace(elapsed time.Duration, hits uint64) (time.Duration, bool) {
+	return pace(p.hits, elapsed, hits)
+}
+
+func (p *SinePacer) HitsPerSecond(elapsed time.Duration) float64 {
+	return p.Mean + p.Amplitude*math.Sin(2*math.Pi*float64(elapsed)/float64(p.Period))
+}
+
+func (p *SinePacer) hits(elapsed time.Duration) float64 {
+	period := p.Period.Seconds()
+
+	return p.Mean*elapsed.Seconds() + p.Amplitude*period/(2*math.Pi)*(1-math.Cos(2*math.Pi*elapsed.Seconds()/period))
+}
+
+func (p *SinePacer) String() string {
+	return fmt.Sprintf("sine %v±%v hits/s every %s", p.Mean, p.Amplitude, p.Period)
+}
+
+// Stage is a part of a schedule changing the rate linearly From the starting rate To the final one over its
+// Duration. Rates are in hits per second.
+type Stage struct {
+	Duration time.Duration
+	From     float64
+	To       float64
+}
+
+// SchedulePacer paces hits according to a piecewise schedule and stops at the end of the last stage.
+type SchedulePacer struct {
+	Stages []Stage
+}
+
+func (p *SchedulePacer) Pace(elapsed time.Duration, hits uint64) (time.Duration, bool) {
+	wait, stop := pace(p.hits, elapsed, hits)
+	if stop || elapsed+wait >= p.Duration() {
+		return 0, true
+	}
+
+	return wait, false
+}
+
+func (p *SchedulePacer) HitsPerSecond(elapsed time.Duration) float64 {
+	for _, s := range p.Stages {
+		if elapsed < s.Duration {
+			return s.From + (s.To-s.From)*elapsed.Seconds()/s.Duration.Seconds()
+		}
+		elapsed -= s.Duration
+	}
+
+	return 0
+}
+
+// Duration returns the total duration of the schedule.
+func (p *SchedulePacer) Duration() time.Duration {
+	total := time.Duration(0)
+	for _, s := range p.Stages {
+		total += s.Duration
+	}
+
+	return total
+}
+
+func (p *SchedulePacer) hits(elapsed time.Duration) float64 {
+	hits := 0.0
+	for _, s := range p.Stages {
+		if elapsed < s.Duration {
+			return hits + rampHits(s.From, s.To, s.Duration.Seconds(), elapsed.Seconds())
+		}
+		hits += rampHits(s.From, s.To, s.Duration.Seconds(), s.Duration.Seconds())
+		elapsed -= s.Duration
+	}
+
+	return hits
+}
+
+func (p *SchedulePacer) String() string {
+	return fmt.Sprintf("schedule of %d stages over %s", len(p.Stages), p.Duration())
+}
+
+// rampHits returns the number of hits after the given seconds of a ramp going linearly from one rate to
+// another over a period in seconds.
+func rampHits(from, to, period, seconds float64) float64 {
+	return from*seconds + (to-from)*seconds*seconds/(2*period)
+}
+
+// ReadSchedule reads a schedule with one stage per line in the "DURATION RATE [END_RATE]" format, where a stage
+// with an end rate ramps linearly towards it. Rates are in hits per second, lines starting with '#' are
+// comments.
+//
+//	30s 10
+//	2m  10 500
+//	1m  500
+func ReadSchedule(r io.Reader) (*SchedulePacer, error) {
+	schedule := &SchedulePacer{}
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		parts := strings.Fields(text)
+		if len(parts) != 2 && len(parts) != 3 {
+			return nil, fmt.Errorf("line %d: '%s' does not match the \"DURATION RATE [END_RATE]\" format", line, text)
+		}
+
+		du, err := time.ParseDuration(parts[0])
+		if err != nil || du <= 0 {
+			return nil, fmt.Errorf("line %d: invalid stage duration '%s'", line, parts[0])
+		}
+
+		rates := make([]float64, 0, 2)
+		for _, p := range parts[1:] {
+			rate, err := strconv.ParseFloat(p, 64)
+			if err != nil || rate < 0 {
+				return nil, fmt.Errorf("line %d: invalid stage rate '%s'", line, p)
+			}
+			rates = append(rates, rate)
+		}
+		if len(rates) == 1 {
+			rates = append(rates, rates[0])
+		}
+
+		schedule.Stages = append(schedule.Stages, Stage{Duration: du, From: rates[0], To: rates[1]})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(schedule.Stages) == 0 {
+		return nil, fmt.Errorf("schedule has no stages")
+	}
+
+	return schedule, nil
+}
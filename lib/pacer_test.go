@@ -0,0 +1,135 @@
+package scurl
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// hitsWithin counts the hits a pacer schedules within the given duration
+func hitsWithin(p Pacer, du time.Duration) int {
+	hits := uint64(0)
+	for {
+		wait, stop := p.Pace(0, hits)
+		if stop || wait >= du {
+			return int(hits)
+		}
+		hits++
+	}
+}
+
+func TestConstantRatePacer(t *testing.T) {
+	rate := &Rate{Freq: 10, Per: time.Second}
+
+	wait, stop := rate.Pace(150*time.Millisecond, 2)
+
+	assert.False(t, stop)
+	assert.Equal(t, 50*time.Millisecond, wait)
+	assert.Equal(t, 10, hitsWithin(rate, time.Second))
+	assert.Equal(t, 10.0, rate.HitsPerSecond(time.Minute))
+}
+
+func TestMaxRateNeverWaits(t *testing.T) {
+	wait, stop := MaxRate.Pace(time.Second, 1000000)
+
+	assert.False(t, stop)
+	assert.Equal(t, time.Duration(0), wait)
+	assert.Equal(t, 0.0, MaxRate.HitsPerSecond(time.Second))
+}
+
+func TestPacerReportsOverdueHits(t *testing.T) {
+	rate := &Rate{Freq: 10, Per: time.Second}
+
+	wait, _ := rate.Pace(time.Second, 5)
+
+	assert.Equal(t, -500*time.Millisecond, wait)
+}
+
+func TestLinearPacer(t *testing.T) {
+	p := &LinearPacer{From: 0, To: 100, Over: time.Second}
+
+	assert.Equal(t, 50, hitsWithin(p, time.Second))
+	assert.Equal(t, 150, hitsWithin(p, 2*time.Second))
+	assert.Equal(t, 50.0, p.HitsPerSecond(500*time.Millisecond))
+	assert.Equal(t, 100.0, p.HitsPerSecond(time.Minute))
+}
+
+func TestStepPacer(t *testing.T) {
+	p := &StepPacer{Start: 10, Step: 10, Every: time.Second}
+
+	assert.Equal(t, 10, hitsWithin(p, time.Second))
+	assert.Equal(t, 30, hitsWithin(p, 2*time.Second))
+	assert.Equal(t, 30.0, p.HitsPerSecond(2500*time.Millisecond))
+}
+
+func TestStepPacerDoesNotGoBelowZero(t *testing.T) {
+	p := &StepPacer{Start: 20, Step: -10, Every: time.Second}
+
+	assert.Equal(t, 30, hitsWithin(p, 2*time.Second))
+	assert.Equal(t, hitsWithin(p, 3*time.Second), hitsWithin(p, 10*time.Second))
+	assert.Equal(t, 0.0, p.HitsPerSecond(10*time.Second))
+}
+
+func TestSinePacer(t *testing.T) {
+	p := &SinePacer{Mean: 100, Amplitude: 50, Period: time.Second}
+
+	assert.InDelta(t, 100, hitsWithin(p, time.Second), 1)
+	assert.InDelta(t, 150.0, p.HitsPerSecond(250*time.Millisecond), 0.001)
+	assert.InDelta(t, 50.0, p.HitsPerSecond(750*time.Millisecond), 0.001)
+}
+
+func TestSchedulePacerStopsAfterLastStage(t *testing.T) {
+	p := &SchedulePacer{Stages: []Stage{
+		{Duration: time.Second, From: 10, To: 10},
+		{Duration: time.Second, From: 10, To: 30},
+	}}
+
+	assert.Equal(t, 30, hitsWithin(p, time.Hour))
+	assert.Equal(t, 2*time.Second, p.Duration())
+	assert.Equal(t, 20.0, p.HitsPerSecond(1500*time.Millisecond))
+	assert.Equal(t, 0.0, p.HitsPerSecond(time.Hour))
+}
+
+func TestReadSchedule(t *testing.T) {
+	input := `
+# warm up
+30s 10
+2m 10 500
+`
+
+	schedule, err := ReadSchedule(strings.NewReader(input))
+
+	assert.Nil(t, err)
+	assert.Equal(t, []Stage{
+		{Duration: 30 * time.Second, From: 10, To: 10},
+		{Duration: 2 * time.Minute, From: 10, To: 500},
+	}, schedule.Stages)
+}
+
+func TestReadInvalidSchedule(t *testing.T) {
+	for _, input := range []string{"", "30s", "30s ten", "-1s 10", "30s 10 20 30"} {
+		_, err := ReadSchedule(strings.NewReader(input))
+
+		assert.NotNil(t, err, input)
+	}
+}
+
+func TestAttackWithPacer(t *testing.T) {
+	server := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+	)
+	defer server.Close()
+
+	req, _ := NewTarget(server.URL)
+	pacer := &LinearPacer{From: 0, To: 200, Over: 200 * time.Millisecond}
+
+	hits := 0
+	for range (&attacker{}).Attack(req, pacer, 200*time.Millisecond) {
+		hits++
+	}
+
+	assert.Equal(t, 20, hits)
+}
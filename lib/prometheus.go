@@ -0,0 +1,61 @@
+package scurl
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+)
+
+// WritePrometheus writes the metrics in the Prometheus text exposition format. Latencies are exposed as a
+// histogram with DefaultBuckets.
+func (m *Metrics) WritePrometheus(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintln(bw, "# HELP scurl_requests_total Requests which received a response, by status code.")
+	fmt.Fprintln(bw, "# TYPE scurl_requests_total counter")
+	codes := make([]int, 0, len(m.StatusCodes))
+	for code := range m.StatusCodes {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+	for _, code := range codes {
+		fmt.Fprintf(bw, "scurl_requests_total{code=\"%d\"} %d\n", code, m.StatusCodes[code])
+	}
+
+	fmt.Fprintln(bw, "# HELP scurl_errors_total Requests which failed without a response, by error class.")
+	fmt.Fprintln(bw, "# TYPE scurl_errors_total counter")
+	classes := make([]string, 0, len(m.Errors))
+	for class := range m.Errors {
+		classes = append(classes, string(class))
+	}
+	sort.Strings(classes)
+	for _, class := range classes {
+		fmt.Fprintf(bw, "scurl_errors_total{class=%q} %d\n", class, m.Errors[ErrorClass(class)])
+	}
+
+	fmt.Fprintln(bw, "# HELP scurl_late_requests_total Requests which waited for a busy worker at the ceiling of workers.")
+	fmt.Fprintln(bw, "# TYPE scurl_late_requests_total counter")
+	fmt.Fprintf(bw, "scurl_late_requests_total %d\n", m.Late)
+
+	fmt.Fprintln(bw, "# HELP scurl_received_bytes_total Bytes of the response bodies received.")
+	fmt.Fprintln(bw, "# TYPE scurl_received_bytes_total counter")
+	fmt.Fprintf(bw, "scurl_received_bytes_total %d\n", m.Bytes)
+
+	fmt.Fprintln(bw, "# HELP scurl_request_duration_seconds Latency of the requests which received a response.")
+	fmt.Fprintln(bw, "# TYPE scurl_request_duration_seconds histogram")
+	cumulative := 0
+	for _, b := range m.Latencies.Histogram(DefaultBuckets...) {
+		cumulative += b.Count
+		le := "+Inf"
+		if !b.Unbounded() {
+			le = strconv.FormatFloat(b.To.Seconds(), 'g', -1, 64)
+		}
+		fmt.Fprintf(bw, "scurl_request_duration_seconds_bucket{le=%q} %d\n", le, cumulative)
+	}
+	fmt.Fprintf(bw, "scurl_request_duration_seconds_sum %s\n", strconv.FormatFloat(m.Latencies.Sum().Seconds(), 'g', -1, 64))
+	fmt.Fprintf(bw, "scurl_request_duration_seconds_count %d\n", m.Latencies.Count())
+
+	return bw.Flush()
+}
@@ -0,0 +1,36 @@
+package scurl
+
+import (
+	"bytes"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestWritePrometheus(t *testing.T) {
+	m := NewMetrics()
+	m.Add(&Response{Response: &http.Response{StatusCode: http.StatusOK}, Time: 3 * time.Millisecond, TotalBytes: 10})
+	m.Add(&Response{Response: &http.Response{StatusCode: http.StatusOK}, Time: 30 * time.Millisecond, TotalBytes: 10, Late: true})
+	m.Add(&Response{Response: &http.Response{StatusCode: http.StatusInternalServerError}, Time: 20 * time.Second})
+	m.Add(&Response{Err: &TripError{Class: TimeoutError, Err: errors.New("timeout")}})
+
+	var buf bytes.Buffer
+	assert.Nil(t, m.WritePrometheus(&buf))
+	out := buf.String()
+
+	assert.Contains(t, out, "# TYPE scurl_requests_total counter\n")
+	assert.Contains(t, out, "scurl_requests_total{code=\"200\"} 2\n")
+	assert.Contains(t, out, "scurl_requests_total{code=\"500\"} 1\n")
+	assert.Contains(t, out, "scurl_errors_total{class=\"timeout\"} 1\n")
+	assert.Contains(t, out, "scurl_received_bytes_total 20\n")
+	assert.Contains(t, out, "scurl_late_requests_total 1\n")
+	assert.Contains(t, out, "scurl_request_duration_seconds_bucket{le=\"0.001\"} 0\n")
+	assert.Contains(t, out, "scurl_request_duration_seconds_bucket{le=\"0.005\"} 1\n")
+	assert.Contains(t, out, "scurl_request_duration_seconds_bucket{le=\"0.05\"} 2\n")
+	assert.Contains(t, out, "scurl_request_duration_seconds_bucket{le=\"10\"} 2\n")
+	assert.Contains(t, out, "scurl_request_duration_seconds_bucket{le=\"+Inf\"} 3\n")
+	assert.Contains(t, out, "scurl_request_duration_seconds_sum 20.033\n")
+	assert.Contains(t, out, "scurl_request_duration_seconds_count 3\n")
+}
@@ -0,0 +1,272 @@
+package scurl
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// recordMagic starts every results file, its last byte is the version of the format
+var recordMagic = []byte("SCURL\x01")
+
+// maxRecordSize bounds the size of the header and of every record, so that a corrupt size is not allocated
+const maxRecordSize = 1 << 20
+
+// Recorder streams results to a compact binary file, which can be read back with a RecordReader.
+//
+// The file starts with a header holding the ReportParams of the attack, followed by one length prefixed record
+// per result. Records consist of varint encoded fields, readers ignore fields they do not know which allows
+// adding fields to the end of a record without breaking existing files.
+type Recorder struct {
+	w   *bufio.Writer
+	buf []byte
+}
+
+// NewRecorder writes the header of a results file with the given params to w.
+func NewRecorder(w io.Writer, params ReportParams) (*Recorder, error) {
+	header, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	if len(header) > maxRecordSize {
+		return nil, fmt.Errorf("results file header exceeds %d bytes", maxRecordSize)
+	}
+
+	rec := &Recorder{w: bufio.NewWriter(w)}
+	buf := append([]byte{}, recordMagic...)
+	buf = binary.AppendUvarint(buf, uint64(len(header)))
+	buf = append(buf, header...)
+	if _, err := rec.w.Write(buf); err != nil {
+		return nil, err
+	}
+
+	return rec, nil
+}
+
+// Record appends a result to the file.
+func (rec *Recorder) Record(r *Response) error {
+	payload := rec.buf[:0]
+
+	// flags: 1 the request was scheduled, 2 it waited for a busy worker
+	flags, late := uint64(0), time.Duration(0)
+	if !r.Intended.IsZero() {
+		flags, late = 1, r.Sent.Sub(r.Intended)
+	}
+	if r.Late {
+		flags |= 2
+	}
+	status := 0
+	if r.Response != nil {
+		status = r.StatusCode
+	}
+	targetID := 0
+	if r.Target != nil {
+		targetID = r.Target.ID
+	}
+	class, msg := "", ""
+	if r.Failed() {
+		class, msg = string(ClassOf(r.Err)), r.Err.Error()
+	}
+
+	payload = binary.AppendVarint(payload, r.Sent.UnixNano())
+	payload = binary.AppendUvarint(payload, flags)
+	payload = binary.AppendVarint(payload, int64(late))
+	payload = binary.AppendVarint(payload, int64(r.Time))
+	payload = binary.AppendUvarint(payload, uint64(status))
+	payload = binary.AppendUvarint(payload, uint64(r.TotalBytes))
+	payload = binary.AppendUvarint(payload, uint64(targetID))
+	payload = appendString(payload, class)
+	payload = appendString(payload, msg)
+	proto := ""
+	if r.Response != nil {
+		proto = r.Proto
+	}
+	// the trailing fields are optional, they are written up to the last one which is set
+	if r.Invalid != nil {
+		payload = appendString(payload, r.Invalid.Rule.String())
+		payload = appendString(payload, r.Invalid.Err.Error())
+	} else if proto != "" || r.Handshake != 0 {
+		// an empty rule stands for a valid response
+		payload = appendString(payload, "")
+		payload = appendString(payload, "")
+	}
+	if proto != "" || r.Handshake != 0 {
+		payload = appendString(payload, proto)
+	}
+	if r.Handshake != 0 {
+		payload = binary.AppendVarint(payload, int64(r.Handshake))
+	}
+	rec.buf = payload
+	if len(payload) > maxRecordSize {
+		return fmt.Errorf("record exceeds %d bytes", maxRecordSize)
+	}
+
+	var size [binary.MaxVarintLen64]byte
+	if _, err := rec.w.Write(size[:binary.PutUvarint(size[:], uint64(len(payload)))]); err != nil {
+		return err
+	}
+	_, err := rec.w.Write(payload)
+	return err
+}
+
+// Flush writes the buffered records to the underlying writer.
+func (rec *Recorder) Flush() error {
+	return rec.w.Flush()
+}
+
+func appendString(buf []byte, s string) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(s)))
+	return append(buf, s...)
+}
+
+// RecordReader reads back the results written by a Recorder.
+type RecordReader struct {
+	r      *bufio.Reader
+	params ReportParams
+	buf    []byte
+}
+
+var ErrNotRecord = errors.New("not a scurl results file")
+
+// NewRecordReader reads the header of the results file in r.
+func NewRecordReader(r io.Reader) (*RecordReader, error) {
+	reader := &RecordReader{r: bufio.NewReader(r)}
+
+	magic := make([]byte, len(recordMagic))
+	if _, err := io.ReadFull(reader.r, magic); err != nil || !bytes.Equal(magic, recordMagic) {
+		return nil, ErrNotRecord
+	}
+
+	size, err := binary.ReadUvarint(reader.r)
+	if err != nil || size > maxRecordSize {
+		return nil, ErrNotRecord
+	}
+	header := make([]byte, size)
+	if _, err := io.ReadFull(reader.r, header); err != nil {
+		return nil, ErrNotRecord
+	}
+	if err := json.Unmarshal(header, &reader.params); err != nil {
+		return nil, fmt.Errorf("invalid results file header: %s", err)
+	}
+
+	return reader, nil
+}
+
+// Params returns the parameters of the recorded attack.
+func (rr *RecordReader) Params() ReportParams {
+	return rr.params
+}
+
+// Next returns the next recorded result, or io.EOF once all results were read. The returned responses have no
+// body and only carry the status code and protocol of the original *http.Response.
+func (rr *RecordReader) Next() (*Response, error) {
+	size, err := binary.ReadUvarint(rr.r)
+	if err != nil {
+		return nil, err
+	}
+	if size > maxRecordSize {
+		return nil, errCorruptRecord
+	}
+	if uint64(cap(rr.buf)) < size {
+		rr.buf = make([]byte, size)
+	}
+	payload := rr.buf[:size]
+	if _, err := io.ReadFull(rr.r, payload); err != nil {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	d := &recordDecoder{buf: payload}
+	sent := d.varint()
+	flags := d.uvarint()
+	late := d.varint()
+	r := &Response{Sent: time.Unix(0, sent), Time: time.Duration(d.varint())}
+	status := int(d.uvarint())
+	r.TotalBytes = int(d.uvarint())
+	r.Target = &Target{ID: int(d.uvarint())}
+	if r.Target.ID < len(rr.params.Steps) {
+		r.Target.Name = rr.params.Steps[r.Target.ID]
+	}
+	class, msg := d.string(), d.string()
+	if len(d.buf) != 0 {
+		if rule, violation := d.string(), d.string(); rule != "" {
+			r.Invalid = &ValidationError{Rule: recordedRule(rule), Err: errors.New(violation)}
+		}
+	}
+	proto := ""
+	if len(d.buf) != 0 {
+		proto = d.string()
+	}
+	if len(d.buf) != 0 {
+		r.Handshake = time.Duration(d.varint())
+	}
+
+	if d.err != nil {
+		return nil, d.err
+	}
+	if flags&1 != 0 {
+		r.Intended = r.Sent.Add(-time.Duration(late))
+	}
+	r.Late = flags&2 != 0
+	if status != 0 {
+		r.Response = &http.Response{StatusCode: status, Proto: proto}
+	}
+	if class != "" {
+		r.Err = &TripError{Class: ErrorClass(class), Err: errors.New(msg)}
+	}
+
+	return r, nil
+}
+
+// recordDecoder decodes the fields of a record, remembering the first failure
+type recordDecoder struct {
+	buf []byte
+	err error
+}
+
+var errCorruptRecord = errors.New("corrupt record")
+
+func (d *recordDecoder) uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(d.buf)
+	if n <= 0 {
+		d.err = errCorruptRecord
+		return 0
+	}
+	d.buf = d.buf[n:]
+	return v
+}
+
+func (d *recordDecoder) varint() int64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Varint(d.buf)
+	if n <= 0 {
+		d.err = errCorruptRecord
+		return 0
+	}
+	d.buf = d.buf[n:]
+	return v
+}
+
+func (d *recordDecoder) string() string {
+	size := d.uvarint()
+	if d.err != nil {
+		return ""
+	}
+	if uint64(len(d.buf)) < size {
+		d.err = errCorruptRecord
+		return ""
+	}
+	s := string(d.buf[:size])
+	d.buf = d.buf[size:]
+	return s
+}
@@ -0,0 +1,128 @@
+package scurl
+
+import (
+	"bytes"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRecordAndReadBack(t *testing.T) {
+	began := time.Unix(1700000000, 0)
+	target := &Target{ID: 3}
+	params := ReportParams{Target: "targets.txt", Targets: 4, FanOut: 2, Duration: time.Minute, Profile: "constant"}
+
+	ok := &Response{Response: &http.Response{StatusCode: http.StatusOK, Proto: "HTTP/2.0"}, Time: 20 * time.Millisecond, TotalBytes: 128,
+		Handshake: 4 * time.Millisecond, Sent: began.Add(5 * time.Millisecond), Intended: began, Late: true, Target: target}
+	invalid := &Response{Response: &http.Response{StatusCode: http.StatusOK, Proto: "HTTP/1.1"}, Sent: began, Target: target,
+		Invalid: &ValidationError{Rule: ExpectBodyContains("ok"), Err: errors.New(`body does not contain "ok"`)}}
+	failed := &Response{Time: time.Second, Sent: began.Add(time.Second), Target: target,
+		Err: &TripError{Class: TimeoutError, Err: errors.New("deadline exceeded")}}
+
+	var buf bytes.Buffer
+	rec, err := NewRecorder(&buf, params)
+	assert.Nil(t, err)
+	assert.Nil(t, rec.Record(ok))
+	assert.Nil(t, rec.Record(invalid))
+	assert.Nil(t, rec.Record(failed))
+	assert.Nil(t, rec.Flush())
+
+	reader, err := NewRecordReader(&buf)
+	assert.Nil(t, err)
+	assert.Equal(t, params, reader.Params())
+
+	r, err := reader.Next()
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, r.StatusCode)
+	assert.Equal(t, "HTTP/2.0", r.Proto)
+	assert.Equal(t, 4*time.Millisecond, r.Handshake)
+	assert.Equal(t, 20*time.Millisecond, r.Time)
+	assert.Equal(t, 128, r.TotalBytes)
+	assert.True(t, ok.Sent.Equal(r.Sent))
+	assert.True(t, began.Equal(r.Intended))
+	assert.True(t, r.Late)
+	assert.Equal(t, 3, r.Target.ID)
+	assert.Nil(t, r.Err)
+	assert.Nil(t, r.Invalid)
+
+	r, err = reader.Next()
+	assert.Nil(t, err)
+	assert.Equal(t, "HTTP/1.1", r.Proto)
+	assert.Equal(t, time.Duration(0), r.Handshake)
+	assert.Equal(t, `body contains "ok"`, r.Invalid.Rule.String())
+	assert.Equal(t, `body contains "ok": body does not contain "ok"`, r.Invalid.Error())
+
+	r, err = reader.Next()
+	assert.Nil(t, err)
+	assert.Nil(t, r.Response)
+	assert.True(t, r.Intended.IsZero())
+	assert.False(t, r.Late)
+	assert.Equal(t, TimeoutError, ClassOf(r.Err))
+	assert.Equal(t, "deadline exceeded", r.Err.Error())
+
+	_, err = reader.Next()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestReadRecordsIntoMetrics(t *testing.T) {
+	began := time.Unix(1700000000, 0)
+
+	var buf bytes.Buffer
+	rec, _ := NewRecorder(&buf, ReportParams{})
+	for i := 0; i < 10; i++ {
+		rec.Record(&Response{Response: &http.Response{StatusCode: http.StatusOK}, Time: time.Duration(i+1) * time.Millisecond,
+			Sent: began.Add(time.Duration(i) * 100 * time.Millisecond), TotalBytes: 10})
+	}
+	rec.Flush()
+
+	reader, err := NewRecordReader(&buf)
+	assert.Nil(t, err)
+
+	m := &Metrics{}
+	for r, err := reader.Next(); err == nil; r, err = reader.Next() {
+		m.Add(r)
+	}
+
+	assert.Equal(t, 10, m.Trips)
+	assert.Equal(t, uint64(100), m.TotalBytes())
+	assert.Equal(t, map[int]int{http.StatusOK: 10}, m.StatusCodes)
+	assert.Equal(t, 910*time.Millisecond, m.TotalTime())
+}
+
+func TestReadNotARecord(t *testing.T) {
+	_, err := NewRecordReader(bytes.NewBufferString("GET http://localhost:8080\n"))
+
+	assert.Equal(t, ErrNotRecord, err)
+}
+
+func TestReadTruncatedRecord(t *testing.T) {
+	var buf bytes.Buffer
+	rec, _ := NewRecorder(&buf, ReportParams{})
+	rec.Record(&Response{Response: &http.Response{StatusCode: http.StatusOK}, Sent: time.Now()})
+	rec.Flush()
+
+	reader, err := NewRecordReader(bytes.NewReader(buf.Bytes()[:buf.Len()-2]))
+	assert.Nil(t, err)
+
+	_, err = reader.Next()
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+}
+
+func TestReadOversizedRecord(t *testing.T) {
+	_, err := NewRecordReader(bytes.NewBufferString("SCURL\x01\xff\xff\xff\xff\xff\xff\xff\xff\xff\x01"))
+	assert.Equal(t, ErrNotRecord, err)
+
+	var buf bytes.Buffer
+	rec, _ := NewRecorder(&buf, ReportParams{})
+	rec.Flush()
+	buf.Write([]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01})
+
+	reader, err := NewRecordReader(&buf)
+	assert.Nil(t, err)
+
+	_, err = reader.Next()
+	assert.Equal(t, errCorruptRecord, err)
+}
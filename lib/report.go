@@ -0,0 +1,146 @@
+package scurl
+
+import (
+	"time"
+)
+
+// ReportPercentiles are the latency percentiles printed in the text report, a Report always has the ones of
+// LatencyReport.
+var ReportPercentiles = []float64{50, 90, 95, 99, 99.9}
+
+// Report is the machine readable summary of an attack, meant to be marshalled to JSON and consumed by other
+// tools. Fields are only ever added to it, never renamed or removed. All durations are in nanoseconds.
+type Report struct {
+	Params         ReportParams   `json:"params"`
+	Trips          int            `json:"trips"`
+	Late           int            `json:"late"` // trips which waited for a busy worker once the ceiling of workers was reached
+	Elapsed        time.Duration  `json:"elapsed"`
+	Throughput     float64        `json:"throughput"`
+	Bytes          uint64         `json:"bytes"`
+	Latencies      LatencyReport  `json:"latencies"`      // service times
+	ResponseTimes  LatencyReport  `json:"response_times"` // response times corrected for coordinated omission
+	Handshakes     uint64         `json:"handshakes"`     // TLS or QUIC handshakes of the connections opened
+	HandshakeTimes LatencyReport  `json:"handshake_times"`
+	StatusCodes    map[int]int    `json:"status_codes"`
+	Protocols      map[string]int `json:"protocols"` // responses by the protocol they were received over
+	Errors         map[string]int `json:"errors"`
+	Invalid        map[string]int `json:"validation_failures"` // responses which violated a rule, by the rule
+	Steps          []*StepReport  `json:"steps,omitempty"`     // per step of a scenario, in the order of the steps
+}
+
+// StepReport summarizes the responses of a single step of a scenario.
+type StepReport struct {
+	Name        string         `json:"name"`
+	Trips       int            `json:"trips"`
+	Latencies   LatencyReport  `json:"latencies"`
+	StatusCodes map[int]int    `json:"status_codes"`
+	Errors      map[string]int `json:"errors"`
+	Invalid     map[string]int `json:"validation_failures"`
+}
+
+// ReportParams are the parameters the attack was run with.
+type ReportParams struct {
+	Target    string        `json:"target"` // URL of the target or path of the targets file
+	Method    string        `json:"method"` // HTTP method of the target, empty for a targets file
+	Targets   int           `json:"targets"`
+	Rate      *Rate         `json:"rate"`
+	Profile   string        `json:"profile"`              // Load profile overriding the constant rate, "constant" if none
+	Users     int           `json:"users,omitempty"`      // Virtual users of a closed loop, which has no rate
+	ThinkTime *ThinkTime    `json:"think_time,omitempty"` // Think time of the virtual users
+	FanOut    int           `json:"fan_out"`
+	Duration  time.Duration `json:"duration"`
+	Steps     []string      `json:"steps,omitempty"` // names of the steps of the scenario, by the Target ID
+}
+
+type LatencyReport struct {
+	Mean time.Duration `json:"mean"`
+	Min  time.Duration `json:"min"`
+	Max  time.Duration `json:"max"`
+	P50  time.Duration `json:"p50"`
+	P90  time.Duration `json:"p90"`
+	P95  time.Duration `json:"p95"`
+	P99  time.Duration `json:"p99"`
+	P999 time.Duration `json:"p99.9"`
+}
+
+// NewReport summarizes the metrics of an attack run with the given params.
+func NewReport(params ReportParams, m *Metrics) *Report {
+	elapsed := m.TotalTime()
+
+	r := &Report{
+		Params:         params,
+		Trips:          m.Trips,
+		Late:           m.Late,
+		Elapsed:        elapsed,
+		Bytes:          m.Bytes,
+		Latencies:      newLatencyReport(&m.Latencies),
+		ResponseTimes:  newLatencyReport(&m.ResponseTimes),
+		Handshakes:     m.Handshakes.Count(),
+		HandshakeTimes: newLatencyReport(&m.Handshakes),
+		StatusCodes:    map[int]int{},
+		Protocols:      map[string]int{},
+		Errors:         map[string]int{},
+		Invalid:        map[string]int{},
+	}
+
+	if elapsed > 0 {
+		r.Throughput = float64(m.Trips) / elapsed.Seconds()
+	}
+	for code, count := range m.StatusCodes {
+		r.StatusCodes[code] = count
+	}
+	for proto, count := range m.Protocols {
+		r.Protocols[proto] = count
+	}
+	for class, count := range m.Errors {
+		r.Errors[string(class)] = count
+	}
+	for rule, count := range m.Invalid {
+		r.Invalid[rule] = count
+	}
+	for _, name := range params.Steps {
+		if step, ok := m.Steps[name]; ok {
+			r.Steps = append(r.Steps, newStepReport(name, step))
+		}
+	}
+
+	return r
+}
+
+func newStepReport(name string, m *Metrics) *StepReport {
+	r := &StepReport{
+		Name:        name,
+		Trips:       m.Trips,
+		Latencies:   newLatencyReport(&m.Latencies),
+		StatusCodes: map[int]int{},
+		Errors:      map[string]int{},
+		Invalid:     map[string]int{},
+	}
+
+	for code, count := range m.StatusCodes {
+		r.StatusCodes[code] = count
+	}
+	for class, count := range m.Errors {
+		r.Errors[string(class)] = count
+	}
+	for rule, count := range m.Invalid {
+		r.Invalid[rule] = count
+	}
+
+	return r
+}
+
+func newLatencyReport(s *Sketch) LatencyReport {
+	ps := s.Percentiles(50, 90, 95, 99, 99.9)
+
+	return LatencyReport{
+		Mean: s.Mean(),
+		Min:  s.Min(),
+		Max:  s.Max(),
+		P50:  ps[0],
+		P90:  ps[1],
+		P95:  ps[2],
+		P99:  ps[3],
+		P999: ps[4],
+	}
+}
@@ -0,0 +1,53 @@
+package scurl
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestNewReport(t *testing.T) {
+	m := NewMetrics()
+	m.Add(&Response{Response: &http.Response{StatusCode: http.StatusOK}, Time: time.Millisecond, TotalBytes: 3})
+	m.Add(&Response{Response: &http.Response{StatusCode: http.StatusNotFound}, Time: 3 * time.Millisecond})
+
+	report := NewReport(ReportParams{Target: "http://localhost", FanOut: 2}, m)
+
+	assert.Equal(t, 2, report.Trips)
+	assert.Equal(t, uint64(3), report.Bytes)
+	assert.Equal(t, time.Millisecond, report.Latencies.Min)
+	assert.Equal(t, 3*time.Millisecond, report.Latencies.Max)
+	assert.Equal(t, map[int]int{http.StatusOK: 1, http.StatusNotFound: 1}, report.StatusCodes)
+	assert.Equal(t, 2, report.Params.FanOut)
+}
+
+func TestReportLatencyPercentiles(t *testing.T) {
+	m := NewMetrics()
+	for i := 1; i <= 100; i++ {
+		m.Add(&Response{Response: &http.Response{StatusCode: http.StatusOK}, Time: time.Duration(i) * time.Millisecond})
+	}
+
+	defer func(ps []float64) { ReportPercentiles = ps }(ReportPercentiles)
+	ReportPercentiles = []float64{99}
+
+	latencies := NewReport(ReportParams{}, m).Latencies
+	assert.Equal(t, m.Percentile(50), latencies.P50)
+	assert.Equal(t, m.Percentile(99.9), latencies.P999)
+}
+
+func TestReportJSONSchema(t *testing.T) {
+	report := NewReport(ReportParams{Rate: &Rate{Freq: 50, Per: time.Second}}, NewMetrics())
+
+	data, err := json.Marshal(report)
+	assert.Nil(t, err)
+
+	var fields map[string]interface{}
+	assert.Nil(t, json.Unmarshal(data, &fields))
+
+	for _, key := range []string{"params", "trips", "elapsed", "throughput", "bytes", "latencies", "handshakes", "status_codes", "protocols", "errors"} {
+		assert.Contains(t, fields, key)
+	}
+	assert.Equal(t, map[string]interface{}{"freq": float64(50), "per": float64(time.Second)}, fields["params"].(map[string]interface{})["rate"])
+}
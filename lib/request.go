@@ -18,6 +18,13 @@ type Target struct {
 	URL    string
 	Body   BodyProvider
 	Header http.Header
+	Weight int    // Relative chance of the target being picked by a weighted Targeter
+	ID     int    // Position of the target in the targets file
+	Rules  []Rule // Rules every response of the target is validated with
+	Name   string // Name of the scenario step the target belongs to, empty outside of scenarios
+
+	template *requestTemplate // renders every request when the target is templated
+	row      Row              // row of the feeder the request is rendered with
 }
 
 func (t *Target) getBody() io.Reader {
@@ -59,6 +66,18 @@ func (b *MultipartFormBody) String() string {
 // Request creates an *http.Request with the provided context.Context out of Target and returns it along with an
 // error in case of failure.
 func (t *Target) RequestWithContext(c context.Context) (*http.Request, error) {
+	if t.template != nil {
+		req, err := t.template.request(t, t.row)
+		if err != nil {
+			return nil, err
+		}
+		if host := req.Header.Get("Host"); host != "" {
+			req.Host = host
+		}
+
+		return req.WithContext(c), nil
+	}
+
 	req, err := http.NewRequest(t.Method, t.URL, t.getBody())
 	if err != nil {
 		return nil, err
@@ -108,7 +127,7 @@ func HeaderOption(headers ...string) ReqOption {
 	return func(req *Target) error {
 
 		for _, v := range headers {
-			parts := strings.Split(v, `:`)
+			parts := strings.SplitN(v, `:`, 2) // values such as "example.com:8080" hold colons of their own
 
 			if len(parts) != 2 {
 				return fmt.Errorf(`header '%s' has a wrong format`, v)
@@ -0,0 +1,310 @@
+package scurl
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Scenario is a flow of requests, such as logging in and calling an API with the obtained token. Every hit of
+// an attack runs the steps of the scenario one after another as a new virtual user. Values extracted from the
+// responses of a step are available to the templates of the later steps as {{.name}}.
+type Scenario struct {
+	Name   string
+	Steps  []*Step
+	Feeder Feeder // provides the initial values of every virtual user, optional
+}
+
+// Step is a request of a scenario. Its Target is templated and named after the step.
+type Step struct {
+	Target  *Target
+	Extract map[string]Extractor // values to extract from the response by their name
+}
+
+// Extractor extracts a value out of a response.
+type Extractor interface {
+	Extract(resp *http.Response, body []byte) (string, error)
+	String() string
+}
+
+type jsonExtractor struct {
+	path string
+	keys []string
+}
+
+// ExtractJSON extracts the value at the path of the JSON body, see ExpectJSON for the format of the path. Values
+// other than strings are extracted in their JSON encoding.
+func ExtractJSON(path string) Extractor {
+	return &jsonExtractor{path: path, keys: jsonPathKeys(path)}
+}
+
+func (e *jsonExtractor) Extract(_ *http.Response, body []byte) (string, error) {
+	value, err := jsonLookup(body, e.path, e.keys)
+	if err != nil {
+		return "", err
+	}
+
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+	encoded, err := json.Marshal(value)
+	return string(encoded), err
+}
+
+func (e *jsonExtractor) String() string {
+	return "json " + e.path
+}
+
+type regexExtractor struct {
+	re *regexp.Regexp
+}
+
+// ExtractRegex extracts the first submatch of the regular expression in the body, or the whole match if the
+// expression has no groups.
+func ExtractRegex(expr string) (Extractor, error) {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regex '%s': %s", expr, err)
+	}
+
+	return &regexExtractor{re: re}, nil
+}
+
+func (e *regexExtractor) Extract(_ *http.Response, body []byte) (string, error) {
+	match := e.re.FindSubmatch(body)
+	if match == nil {
+		return "", fmt.Errorf("body does not match %q", e.re)
+	}
+	if len(match) > 1 {
+		return string(match[1]), nil
+	}
+
+	return string(match[0]), nil
+}
+
+func (e *regexExtractor) String() string {
+	return fmt.Sprintf("regex %q", e.re)
+}
+
+type headerExtractor struct {
+	name string
+}
+
+// ExtractHeader extracts the value of the header of the response.
+func ExtractHeader(name string) Extractor {
+	return &headerExtractor{name: name}
+}
+
+func (e *headerExtractor) Extract(resp *http.Response, _ []byte) (string, error) {
+	values, ok := resp.Header[http.CanonicalHeaderKey(e.name)]
+	if !ok || len(values) == 0 {
+		return "", fmt.Errorf("header %s is missing", e.name)
+	}
+
+	return values[0], nil
+}
+
+func (e *headerExtractor) String() string {
+	return "header " + e.name
+}
+
+// extraction is the Rule a response violates when a value cannot be extracted from it
+type extraction struct {
+	name      string
+	extractor Extractor
+}
+
+func (e *extraction) Validate(resp *http.Response, body []byte) error {
+	_, err := e.extractor.Extract(resp, body)
+	return err
+}
+
+func (e *extraction) String() string {
+	return fmt.Sprintf("extract %s from %s", e.name, e.extractor)
+}
+
+// extract adds the values extracted from the response to vars, returning the first extraction which failed.
+func (s *Step) extract(resp *http.Response, body []byte, vars Row) *ValidationError {
+	names := make([]string, 0, len(s.Extract))
+	for name := range s.Extract {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		value, err := s.Extract[name].Extract(resp, body)
+		if err != nil {
+			return &ValidationError{Rule: &extraction{name: name, extractor: s.Extract[name]}, Err: err}
+		}
+		vars[name] = value
+	}
+
+	return nil
+}
+
+// StepNames returns the names of the steps in their order, which is the order of the ReportParams.Steps.
+func (s *Scenario) StepNames() []string {
+	names := make([]string, len(s.Steps))
+	for i, step := range s.Steps {
+		names[i] = step.Target.Name
+	}
+
+	return names
+}
+
+// yamlScenario is a scenario in the YAML format
+type yamlScenario struct {
+	Name  string     `yaml:"name"`
+	Steps []yamlStep `yaml:"steps"`
+}
+
+type yamlStep struct {
+	Name    string                       `yaml:"name"`
+	Method  string                       `yaml:"method"`
+	URL     string                       `yaml:"url"`
+	Headers map[string]string            `yaml:"headers"`
+	Body    string                       `yaml:"body"`
+	Expect  *yamlExpect                  `yaml:"expect"`
+	Extract map[string]map[string]string `yaml:"extract"`
+}
+
+type yamlExpect struct {
+	Status       []int                  `yaml:"status"`
+	BodyContains string                 `yaml:"body_contains"`
+	BodyRegex    string                 `yaml:"body_regex"`
+	JSON         map[string]interface{} `yaml:"json"`
+	Headers      []string               `yaml:"headers"`
+	MaxBodySize  int                    `yaml:"max_body_size"`
+}
+
+// ReadScenario reads a scenario in the YAML format. Every step is a request whose url, headers and body are
+// templates, see TemplateOption. Values extracted from its response by a json path, a regex or a header are
+// available to the later steps under their name, rules under expect validate the response like the rules of
+// targets in the JSON-lines format.
+//
+//	name: checkout
+//	steps:
+//	  - name: login
+//	    method: POST
+//	    url: http://localhost:8080/login
+//	    headers:
+//	      Content-Type: application/json
+//	    body: '{"user": "ann"}'
+//	    expect:
+//	      status: [200]
+//	    extract:
+//	      token: {json: $.token}
+//	  - name: profile
+//	    url: http://localhost:8080/me
+//	    headers:
+//	      Authorization: Bearer {{.token}}
+func ReadScenario(r io.Reader) (*Scenario, error) {
+	var ys yamlScenario
+	dec := yaml.NewDecoder(r)
+	dec.KnownFields(true)
+	if err := dec.Decode(&ys); err != nil {
+		return nil, err
+	}
+	if len(ys.Steps) == 0 {
+		return nil, fmt.Errorf("scenario has no steps")
+	}
+
+	scenario := &Scenario{Name: ys.Name}
+	names := map[string]bool{}
+	for i, ystep := range ys.Steps {
+		step, err := ystep.step(i)
+		if err != nil {
+			return nil, fmt.Errorf("step %d: %s", i+1, err)
+		}
+		if names[step.Target.Name] {
+			return nil, fmt.Errorf("step %d: duplicate step name '%s'", i+1, step.Target.Name)
+		}
+		names[step.Target.Name] = true
+		scenario.Steps = append(scenario.Steps, step)
+	}
+
+	return scenario, nil
+}
+
+func (ys *yamlStep) step(i int) (*Step, error) {
+	// header values are set as is, they may contain colons unlike the values of HeaderOption
+	setHeaders := func(req *Target) error {
+		for key, value := range ys.Headers {
+			if req.Header == nil {
+				req.Header = http.Header{}
+			}
+			req.Header[key] = append(req.Header[key], value)
+		}
+		return nil
+	}
+
+	options := []ReqOption{MethodOption(ys.Method), StringBodyOption(ys.Body), setHeaders}
+	if ys.Expect != nil {
+		rules, err := ys.Expect.rules()
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, ExpectOption(rules...))
+	}
+	options = append(options, TemplateOption())
+
+	t, err := NewTarget(ys.URL, options...)
+	if err != nil {
+		return nil, err
+	}
+	t.ID = i
+	t.Name = ys.Name
+	if t.Name == "" {
+		t.Name = fmt.Sprintf("%s %s", t.Method, t.URL)
+	}
+
+	step := &Step{Target: t, Extract: map[string]Extractor{}}
+	for name, spec := range ys.Extract {
+		if len(spec) != 1 {
+			return nil, fmt.Errorf("extract %s needs exactly one of json, regex or header", name)
+		}
+		for kind, arg := range spec {
+			switch kind {
+			case "json":
+				step.Extract[name] = ExtractJSON(arg)
+			case "regex":
+				ex, err := ExtractRegex(arg)
+				if err != nil {
+					return nil, err
+				}
+				step.Extract[name] = ex
+			case "header":
+				step.Extract[name] = ExtractHeader(arg)
+			default:
+				return nil, fmt.Errorf("extract %s has an unknown extractor '%s', supported are [json regex header]", name, kind)
+			}
+		}
+	}
+
+	return step, nil
+}
+
+func (ye *yamlExpect) rules() ([]Rule, error) {
+	expect := &jsonExpect{
+		Status:       ye.Status,
+		BodyContains: ye.BodyContains,
+		BodyRegex:    ye.BodyRegex,
+		Headers:      ye.Headers,
+		MaxBodySize:  ye.MaxBodySize,
+		JSON:         map[string]json.RawMessage{},
+	}
+	for path, value := range ye.JSON {
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid expected value of %s: %s", path, err)
+		}
+		expect.JSON[path] = encoded
+	}
+
+	return expect.rules()
+}
@@ -0,0 +1,172 @@
+package scurl
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const scenarioYAML = `
+name: profile
+steps:
+  - name: login
+    method: POST
+    url: %[1]s/login
+    headers:
+      Content-Type: application/json
+    body: '{"user": "{{.user}}"}'
+    expect:
+      status: [200]
+      json:
+        $.ok: true
+    extract:
+      token: {json: $.token}
+      session: {header: X-Session}
+  - name: me
+    url: %[1]s/me?session={{.session}}
+    headers:
+      Authorization: "Bearer: {{.token}}"
+    extract:
+      id: {regex: 'id=(\d+)'}
+  - url: %[1]s/users/{{.id}}
+`
+
+func scenarioServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			w.Header().Set("X-Session", "s1")
+			fmt.Fprint(w, `{"ok": true, "token": "abc"}`)
+		case "/me":
+			if r.Header.Get("Authorization") != "Bearer: abc" || r.URL.Query().Get("session") != "s1" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			fmt.Fprint(w, "id=42")
+		case "/users/42":
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestReadScenario(t *testing.T) {
+	s, err := ReadScenario(strings.NewReader(fmt.Sprintf(scenarioYAML, "http://localhost")))
+	assert.Nil(t, err)
+
+	assert.Equal(t, "profile", s.Name)
+	assert.Equal(t, []string{"login", "me", "GET http://localhost/users/{{.id}}"}, s.StepNames())
+	assert.Equal(t, http.MethodPost, s.Steps[0].Target.Method)
+	assert.Equal(t, 2, len(s.Steps[0].Target.Rules))
+	assert.Equal(t, 2, len(s.Steps[0].Extract))
+	assert.Equal(t, 2, s.Steps[2].Target.ID)
+
+	for _, invalid := range []string{
+		"steps: []",
+		"steps:\n  - url: http://localhost\n    unknown: 1",
+		"steps:\n  - url: http://localhost\n    extract:\n      id: {xpath: /id}",
+		"steps:\n  - url: http://localhost\n    extract:\n      id: {regex: '('}",
+		"steps:\n  - name: a\n    url: http://localhost\n  - name: a\n    url: http://localhost",
+		"steps:\n  - url: http://localhost/{{.id",
+	} {
+		_, err := ReadScenario(strings.NewReader(invalid))
+		assert.NotNil(t, err, invalid)
+	}
+}
+
+func TestExtractors(t *testing.T) {
+	resp := &http.Response{Header: http.Header{"X-Id": {"7"}}}
+	body := []byte(`{"data": {"items": [{"id": 1, "name": "a"}]}, "id": "x-9"}`)
+
+	re, err := ExtractRegex(`x-(\d)`)
+	assert.Nil(t, err)
+	whole, _ := ExtractRegex(`x-\d`)
+
+	for extractor, expected := range map[Extractor]string{
+		ExtractJSON("$.data.items[0].name"): "a",
+		ExtractJSON("data.items.0"):         `{"id":1,"name":"a"}`,
+		ExtractHeader("x-id"):               "7",
+		re:                                  "9",
+		whole:                               "x-9",
+	} {
+		value, err := extractor.Extract(resp, body)
+		assert.Nil(t, err, extractor.String())
+		assert.Equal(t, expected, value, extractor.String())
+	}
+
+	for _, extractor := range []Extractor{ExtractJSON("$.missing"), ExtractHeader("X-Missing"), re} {
+		_, err := extractor.Extract(resp, []byte("{}"))
+		assert.NotNil(t, err, extractor.String())
+	}
+}
+
+func TestPlayScenario(t *testing.T) {
+	fs := scenarioServer()
+	defer fs.Close()
+
+	s, err := ReadScenario(strings.NewReader(fmt.Sprintf(scenarioYAML, fs.URL)))
+	assert.Nil(t, err)
+	s.Feeder = NewFeeder([]Row{{"user": "ann"}, {"user": "bob"}}, SequentialFeed, StopOnExhaustion)
+
+	client := NewConcurrentClient(
+		FanOutOpt(1),
+		RateOpt(&Rate{Freq: 100, Per: time.Second}),
+		DurationOpt(5*time.Second),
+	)
+
+	metrics := NewMetrics()
+	for r := range client.DoScenario(s) {
+		r.ReadAndDiscard()
+		metrics.Add(r)
+	}
+
+	assert.Nil(t, client.Err())
+	assert.Equal(t, 6, metrics.Trips)
+	assert.Equal(t, map[int]int{200: 6}, metrics.StatusCodes)
+	assert.Equal(t, 0, metrics.InvalidCount())
+	for _, name := range s.StepNames() {
+		assert.Equal(t, 2, metrics.Steps[name].Trips, name)
+	}
+
+	report := NewReport(ReportParams{Steps: s.StepNames()}, metrics)
+	assert.Equal(t, 3, len(report.Steps))
+	assert.Equal(t, "login", report.Steps[0].Name)
+	assert.Equal(t, map[int]int{200: 2}, report.Steps[1].StatusCodes)
+}
+
+func TestAbortScenarioOnFailedExtraction(t *testing.T) {
+	var hits int64
+	fs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&hits, 1)
+		fmt.Fprint(w, "{}")
+	}))
+	defer fs.Close()
+
+	s, err := ReadScenario(strings.NewReader(fmt.Sprintf(scenarioYAML, fs.URL)))
+	assert.Nil(t, err)
+	s.Steps[0].Target.Rules = nil
+	s.Feeder = NewFeeder([]Row{{"user": "ann"}}, CircularFeed, WrapOnExhaustion)
+
+	client := NewConcurrentClient(
+		FanOutOpt(1),
+		RateOpt(&Rate{Freq: 50, Per: time.Second}),
+		DurationOpt(100*time.Millisecond),
+	)
+
+	metrics := NewMetrics()
+	for r := range client.DoScenario(s) {
+		assert.Equal(t, "login", r.Target.Name)
+		assert.NotNil(t, r.Invalid)
+		metrics.Add(r)
+	}
+
+	assert.Nil(t, client.Err())
+	assert.True(t, metrics.Trips > 0)
+	assert.Equal(t, int64(metrics.Trips), atomic.LoadInt64(&hits))
+	assert.Equal(t, map[string]int{"extract session from header X-Session": metrics.Trips}, metrics.Steps["login"].Invalid)
+}
@@ -0,0 +1,141 @@
+package scurl
+
+import (
+	"fmt"
+	"time"
+)
+
+// RateSearch searches for the highest rate an attack can run at while its metrics satisfy an SLO. It runs
+// successive short phases, doubling the rate from Min until a phase violates the SLO or Max passed, then bisects
+// between the highest passing and the lowest failing rate.
+type RateSearch struct {
+	Min       int          // lowest rate to try in requests per second
+	Max       int          // highest rate to try in requests per second
+	Precision int          // the search ends once the highest passing and the lowest failing rate are this close
+	SLO       []*Threshold // thresholds the metrics of a phase have to satisfy for its rate to pass
+}
+
+// SearchPhase is a phase of a RateSearch run at a constant rate.
+type SearchPhase struct {
+	Rate       *Rate
+	Metrics    *Metrics
+	Violations []string // the thresholds of the SLO the phase violated along with the actual values
+}
+
+// Passed reports whether the phase satisfied the SLO.
+func (p *SearchPhase) Passed() bool {
+	return len(p.Violations) == 0
+}
+
+// SearchResult is the outcome of a RateSearch.
+type SearchResult struct {
+	Rate   *Rate // the highest rate which satisfied the SLO, nil if not even the lowest rate did
+	Phases []*SearchPhase
+}
+
+// Run runs the phases of the search, attack runs a phase at the given rate and returns its metrics. An error
+// returned by attack ends the search.
+func (s *RateSearch) Run(attack func(*Rate) (*Metrics, error)) (*SearchResult, error) {
+	if s.Min < 1 || s.Max < s.Min {
+		return nil, fmt.Errorf("invalid search range [%d, %d]", s.Min, s.Max)
+	}
+	precision := s.Precision
+	if precision < 1 {
+		precision = 1
+	}
+
+	result := &SearchResult{}
+	phase := func(rps int) (bool, error) {
+		rate := &Rate{Freq: rps, Per: time.Second}
+		m, err := attack(rate)
+		if err != nil {
+			return false, err
+		}
+
+		p := &SearchPhase{Rate: rate, Metrics: m}
+		for _, th := range s.SLO {
+			if actual, ok := th.Check(m); !ok {
+				p.Violations = append(p.Violations, fmt.Sprintf("%s (actual %s)", th, th.Format(actual)))
+			}
+		}
+		result.Phases = append(result.Phases, p)
+		if p.Passed() {
+			result.Rate = rate
+		}
+		return p.Passed(), nil
+	}
+
+	passed, err := phase(s.Min)
+	if err != nil || !passed {
+		return result, err
+	}
+
+	// lo passed, hi failed or is beyond Max
+	lo, hi := s.Min, s.Max+1
+	for lo < s.Max {
+		next := lo * 2
+		if next > s.Max {
+			next = s.Max
+		}
+
+		passed, err := phase(next)
+		if err != nil {
+			return result, err
+		}
+		if !passed {
+			hi = next
+			break
+		}
+		lo = next
+	}
+
+	for hi <= s.Max && hi-lo > precision {
+		mid := lo + (hi-lo)/2
+
+		passed, err := phase(mid)
+		if err != nil {
+			return result, err
+		}
+		if passed {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+
+	return result, nil
+}
+
+// SearchReport is the machine readable summary of a RateSearch, see Report.
+type SearchReport struct {
+	Rate   *Rate                `json:"rate"` // the highest rate which satisfied the SLO, null if none did
+	SLO    []string             `json:"slo"`
+	Phases []*SearchPhaseReport `json:"phases"`
+}
+
+// SearchPhaseReport summarizes a phase of a RateSearch.
+type SearchPhaseReport struct {
+	Passed     bool     `json:"passed"`
+	Violations []string `json:"violations"`
+	*Report
+}
+
+// NewSearchReport summarizes the phases of a search of the given SLO, params are the parameters shared by all of
+// its phases.
+func NewSearchReport(params ReportParams, slo []*Threshold, result *SearchResult) *SearchReport {
+	r := &SearchReport{Rate: result.Rate, SLO: []string{}, Phases: []*SearchPhaseReport{}}
+	for _, th := range slo {
+		r.SLO = append(r.SLO, th.String())
+	}
+
+	for _, p := range result.Phases {
+		params.Rate = p.Rate
+		violations := p.Violations
+		if violations == nil {
+			violations = []string{}
+		}
+		r.Phases = append(r.Phases, &SearchPhaseReport{Passed: p.Passed(), Violations: violations, Report: NewReport(params, p.Metrics)})
+	}
+
+	return r
+}
@@ -0,0 +1,125 @@
+package scurl
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// capacity returns an attack whose p99 latency exceeds 50ms above the given rate
+func capacity(limit int, rates *[]int) func(*Rate) (*Metrics, error) {
+	return func(rate *Rate) (*Metrics, error) {
+		*rates = append(*rates, rate.Freq)
+
+		latency := 10 * time.Millisecond
+		if rate.Freq > limit {
+			latency = 100 * time.Millisecond
+		}
+		m := NewMetrics()
+		for i := 0; i < 10; i++ {
+			m.Add(&Response{Response: &http.Response{StatusCode: http.StatusOK}, Time: latency})
+		}
+		return m, nil
+	}
+}
+
+func slo(t *testing.T, specs ...string) []*Threshold {
+	var list []*Threshold
+	for _, spec := range specs {
+		th, err := ParseThreshold(spec)
+		assert.Nil(t, err, spec)
+		list = append(list, th)
+	}
+	return list
+}
+
+func TestSearchHighestRateMeetingSLO(t *testing.T) {
+	var rates []int
+	search := &RateSearch{Min: 10, Max: 1000, Precision: 5, SLO: slo(t, "p99<50ms")}
+
+	result, err := search.Run(capacity(333, &rates))
+
+	assert.Nil(t, err)
+	assert.Equal(t, []int{10, 20, 40, 80, 160, 320, 640, 480, 400, 360, 340, 330, 335}, rates)
+	assert.Equal(t, &Rate{Freq: 330, Per: time.Second}, result.Rate)
+	assert.Len(t, result.Phases, len(rates))
+	assert.True(t, result.Phases[5].Passed())
+	assert.False(t, result.Phases[6].Passed())
+	assert.Equal(t, []string{"p99<50ms (actual 100ms)"}, result.Phases[6].Violations)
+}
+
+func TestSearchPassingMax(t *testing.T) {
+	var rates []int
+	search := &RateSearch{Min: 100, Max: 300, SLO: slo(t, "p99<50ms")}
+
+	result, err := search.Run(capacity(1000, &rates))
+
+	assert.Nil(t, err)
+	assert.Equal(t, []int{100, 200, 300}, rates)
+	assert.Equal(t, 300, result.Rate.Freq)
+}
+
+func TestSearchFailingMin(t *testing.T) {
+	var rates []int
+	search := &RateSearch{Min: 100, Max: 300, SLO: slo(t, "p99<50ms")}
+
+	result, err := search.Run(capacity(50, &rates))
+
+	assert.Nil(t, err)
+	assert.Equal(t, []int{100}, rates)
+	assert.Nil(t, result.Rate)
+}
+
+func TestSearchErrors(t *testing.T) {
+	_, err := (&RateSearch{Min: 0, Max: 10}).Run(nil)
+	assert.NotNil(t, err)
+	_, err = (&RateSearch{Min: 20, Max: 10}).Run(nil)
+	assert.NotNil(t, err)
+
+	calls := 0
+	result, err := (&RateSearch{Min: 10, Max: 100}).Run(func(*Rate) (*Metrics, error) {
+		calls++
+		if calls == 2 {
+			return nil, errors.New("stopped")
+		}
+		return NewMetrics(), nil
+	})
+	assert.EqualError(t, err, "stopped")
+	assert.Equal(t, 10, result.Rate.Freq)
+	assert.Len(t, result.Phases, 1)
+}
+
+func TestSearchReportJSON(t *testing.T) {
+	var rates []int
+	thresholds := slo(t, "p99<50ms")
+	result, _ := (&RateSearch{Min: 100, Max: 200, SLO: thresholds}).Run(capacity(150, &rates))
+
+	data, err := json.Marshal(NewSearchReport(ReportParams{Target: "http://localhost"}, thresholds, result))
+	assert.Nil(t, err)
+
+	var report struct {
+		Rate   *Rate    `json:"rate"`
+		SLO    []string `json:"slo"`
+		Phases []struct {
+			Passed     bool         `json:"passed"`
+			Violations []string     `json:"violations"`
+			Params     ReportParams `json:"params"`
+			Trips      int          `json:"trips"`
+		} `json:"phases"`
+	}
+	assert.Nil(t, json.Unmarshal(data, &report))
+
+	assert.Equal(t, 150, report.Rate.Freq)
+	assert.Equal(t, []string{"p99<50ms"}, report.SLO)
+	assert.Len(t, report.Phases, len(rates))
+	assert.True(t, report.Phases[0].Passed)
+	assert.Equal(t, []string{}, report.Phases[0].Violations)
+	assert.Equal(t, 100, report.Phases[0].Params.Rate.Freq)
+	assert.Equal(t, "http://localhost", report.Phases[0].Params.Target)
+	assert.Equal(t, 10, report.Phases[0].Trips)
+	assert.False(t, report.Phases[1].Passed)
+	assert.Equal(t, 200, report.Phases[1].Params.Rate.Freq)
+}
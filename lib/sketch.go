@@ -0,0 +1,165 @@
+package scurl
+
+import (
+	"math/bits"
+	"time"
+)
+
+const (
+	sketchSubBits   = 7
+	sketchSubCount  = 1 << sketchSubBits
+	sketchHalfCount = sketchSubCount / 2
+	sketchSize      = sketchSubCount + (64-sketchSubBits)*sketchHalfCount
+)
+
+// Sketch is a log-linear histogram of durations in the spirit of HDR histograms. It keeps every recorded value
+// within ~1% of its real value in constant memory, no matter how many values are recorded. Sketches can be
+// merged, which allows aggregating latencies of independent attackers, time windows or runs.
+type Sketch struct {
+	counts []uint64
+	total  uint64
+	sum    time.Duration
+	min    time.Duration
+	max    time.Duration
+}
+
+func (s *Sketch) Add(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	if s.counts == nil {
+		s.counts = make([]uint64, sketchSize)
+	}
+
+	s.counts[sketchIndex(uint64(d))]++
+	if s.total == 0 || d < s.min {
+		s.min = d
+	}
+	if d > s.max {
+		s.max = d
+	}
+	s.sum += d
+	s.total++
+}
+
+// Merge adds all values recorded by other to s.
+func (s *Sketch) Merge(other *Sketch) {
+	if other == nil || other.total == 0 {
+		return
+	}
+	if s.counts == nil {
+		s.counts = make([]uint64, sketchSize)
+	}
+
+	for i, c := range other.counts {
+		s.counts[i] += c
+	}
+	if s.total == 0 || other.min < s.min {
+		s.min = other.min
+	}
+	if other.max > s.max {
+		s.max = other.max
+	}
+	s.sum += other.sum
+	s.total += other.total
+}
+
+func (s *Sketch) Reset() {
+	*s = Sketch{}
+}
+
+func (s *Sketch) Count() uint64 {
+	return s.total
+}
+
+func (s *Sketch) Min() time.Duration {
+	return s.min
+}
+
+func (s *Sketch) Max() time.Duration {
+	return s.max
+}
+
+// Sum returns the total of the recorded values.
+func (s *Sketch) Sum() time.Duration {
+	return s.sum
+}
+
+func (s *Sketch) Mean() time.Duration {
+	if s.total == 0 {
+		return 0
+	}
+	return s.sum / time.Duration(s.total)
+}
+
+// Percentiles returns the approximate values at the requested percentiles (0 < p <= 100).
+func (s *Sketch) Percentiles(ps ...float64) []time.Duration {
+	result := make([]time.Duration, len(ps))
+	if s.total == 0 {
+		return result
+	}
+
+	for i, p := range ps {
+		target := uint64(rank(p, int(s.total))) + 1
+
+		seen := uint64(0)
+		for idx, c := range s.counts {
+			if seen += c; seen >= target {
+				result[i] = s.clamp(sketchValue(idx))
+				break
+			}
+		}
+	}
+
+	return result
+}
+
+func (s *Sketch) Percentile(p float64) time.Duration {
+	return s.Percentiles(p)[0]
+}
+
+// Histogram buckets the recorded values the same way MultiResponse.Histogram does.
+func (s *Sketch) Histogram(bounds ...time.Duration) []Bucket {
+	buckets := newBuckets(bounds)
+
+	for idx, c := range s.counts {
+		if c != 0 {
+			buckets[bucketIndex(buckets, s.clamp(sketchValue(idx)))].Count += int(c)
+		}
+	}
+
+	return buckets
+}
+
+func (s *Sketch) clamp(d time.Duration) time.Duration {
+	if d < s.min {
+		return s.min
+	}
+	if d > s.max {
+		return s.max
+	}
+	return d
+}
+
+func sketchIndex(v uint64) int {
+	if v < sketchSubCount {
+		return int(v)
+	}
+
+	shift := bits.Len64(v) - sketchSubBits
+	return sketchSubCount + (shift-1)*sketchHalfCount + int(v>>uint(shift)) - sketchHalfCount
+}
+
+// sketchValue returns the value in the middle of the range covered by the bucket at idx.
+func sketchValue(idx int) time.Duration {
+	if idx < sketchSubCount {
+		return time.Duration(idx)
+	}
+
+	shift := uint((idx-sketchSubCount)/sketchHalfCount + 1)
+	mantissa := uint64((idx-sketchSubCount)%sketchHalfCount + sketchHalfCount)
+	lo := mantissa << shift
+	hi := (mantissa+1)<<shift - 1
+
+	return time.Duration(lo + (hi-lo)/2)
+}
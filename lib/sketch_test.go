@@ -0,0 +1,74 @@
+package scurl
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestSketchPercentilesAreWithinOnePercent(t *testing.T) {
+	s := &Sketch{}
+	resp := &MultiResponse{}
+
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 10000; i++ {
+		d := time.Duration(rnd.ExpFloat64() * float64(20*time.Millisecond))
+		s.Add(d)
+		resp.Add(&Response{Time: d})
+	}
+
+	ps := []float64{50, 90, 99, 99.9}
+	actual := s.Percentiles(ps...)
+	expected := resp.Percentiles(ps...)
+
+	for i := range ps {
+		diff := math.Abs(float64(actual[i]-expected[i])) / float64(expected[i])
+		assert.True(t, diff < 0.01, "p%v got: %v, want: %v", ps[i], actual[i], expected[i])
+	}
+	assert.Equal(t, resp.Slowest().Time, s.Max())
+	assert.Equal(t, resp.Fastest().Time, s.Min())
+	assert.Equal(t, uint64(10000), s.Count())
+}
+
+func TestSketchSmallValuesAreExact(t *testing.T) {
+	s := &Sketch{}
+	for i := 1; i <= 100; i++ {
+		s.Add(time.Duration(i))
+	}
+
+	assert.Equal(t, []time.Duration{50, 99, 100}, s.Percentiles(50, 99, 100))
+	assert.Equal(t, time.Duration(50), s.Mean())
+}
+
+func TestMergeSketches(t *testing.T) {
+	first, second := &Sketch{}, &Sketch{}
+	first.Add(10 * time.Millisecond)
+	second.Add(1 * time.Millisecond)
+	second.Add(1 * time.Second)
+
+	first.Merge(second)
+
+	assert.Equal(t, uint64(3), first.Count())
+	assert.Equal(t, 1*time.Millisecond, first.Min())
+	assert.Equal(t, 1*time.Second, first.Max())
+}
+
+func TestPercentileOfEmptySketch(t *testing.T) {
+	s := &Sketch{}
+
+	assert.Equal(t, time.Duration(0), s.Percentile(99))
+	assert.Equal(t, time.Duration(0), s.Mean())
+}
+
+func TestSketchHistogram(t *testing.T) {
+	s := &Sketch{}
+	for _, d := range []time.Duration{1, 5, 10, 15, 20, 30} {
+		s.Add(d * time.Millisecond)
+	}
+
+	buckets := s.Histogram(10*time.Millisecond, 20*time.Millisecond)
+
+	assert.Equal(t, []int{2, 2, 2}, []int{buckets[0].Count, buckets[1].Count, buckets[2].Count})
+}
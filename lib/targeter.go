@@ -0,0 +1,281 @@
+package scurl
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Targeter provides the target of every hit of an attack. Implementations must be safe for concurrent use.
+type Targeter interface {
+	Next() (*Target, error)
+}
+
+// Next implements Targeter for a single Target which is hit over and over.
+func (t *Target) Next() (*Target, error) {
+	return t, nil
+}
+
+var ErrNoTargets = errors.New("no targets")
+
+type roundRobinTargeter struct {
+	targets []*Target
+	next    uint64
+}
+
+// NewRoundRobinTargeter hits the targets one after another in the order provided.
+func NewRoundRobinTargeter(targets ...*Target) Targeter {
+	return &roundRobinTargeter{targets: targets}
+}
+
+func (t *roundRobinTargeter) Next() (*Target, error) {
+	if len(t.targets) == 0 {
+		return nil, ErrNoTargets
+	}
+
+	i := atomic.AddUint64(&t.next, 1) - 1
+	return t.targets[i%uint64(len(t.targets))], nil
+}
+
+type weightedTargeter struct {
+	targets []*Target
+	bounds  []int // cumulative weights of the targets
+	mu      sync.Mutex
+	rnd     *rand.Rand
+}
+
+// NewRandomTargeter hits a random target out of the provided ones, each target being equally likely.
+func NewRandomTargeter(targets ...*Target) Targeter {
+	uniform := make([]*Target, len(targets))
+	for i, t := range targets {
+		copied := *t
+		copied.Weight = 1
+		uniform[i] = &copied
+	}
+
+	return NewWeightedTargeter(uniform...)
+}
+
+// NewWeightedTargeter hits a random target out of the provided ones, with the chance of a target being picked
+// proportional to its Weight. Targets without a weight have a weight of 1.
+func NewWeightedTargeter(targets ...*Target) Targeter {
+	bounds := make([]int, len(targets))
+	total := 0
+	for i, t := range targets {
+		weight := t.Weight
+		if weight < 1 {
+			weight = 1
+		}
+		total += weight
+		bounds[i] = total
+	}
+
+	return &weightedTargeter{targets: targets, bounds: bounds, rnd: rand.New(rand.NewSource(time.Now().UnixNano()))}
+}
+
+func (t *weightedTargeter) Next() (*Target, error) {
+	if len(t.targets) == 0 {
+		return nil, ErrNoTargets
+	}
+
+	t.mu.Lock()
+	n := t.rnd.Intn(t.bounds[len(t.bounds)-1])
+	t.mu.Unlock()
+
+	for i, bound := range t.bounds {
+		if n < bound {
+			return t.targets[i], nil
+		}
+	}
+
+	return t.targets[len(t.targets)-1], nil
+}
+
+// ReadTextTargets reads targets in the text format, where each target starts with a "METHOD URL" line,
+// optionally followed by "Key: Value" header lines and a "@path" line naming the file holding the body. Relative
+// body paths are resolved against dir, the directory of the targets file. Targets may be separated by blank lines,
+// lines starting with '#' are comments.
+//
+//	POST http://localhost:8080/users
+//	Content-Type: application/json
+//	@user.json
+//
+//	GET http://localhost:8080/users
+func ReadTextTargets(r io.Reader, dir string) ([]*Target, error) {
+	var targets []*Target
+	var current *Target
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case text == "" || strings.HasPrefix(text, "#"):
+			continue
+
+		case strings.HasPrefix(text, "@"):
+			if current == nil {
+				return nil, fmt.Errorf("line %d: body '%s' does not belong to a target", line, text)
+			}
+			path := text[1:]
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(dir, path)
+			}
+			body, err := ioutil.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %s", line, err)
+			}
+			if err := StringBodyOption(string(body))(current); err != nil {
+				return nil, fmt.Errorf("line %d: %s", line, err)
+			}
+
+		case isRequestLine(text):
+			parts := strings.Fields(text)
+			if len(parts) != 2 {
+				return nil, fmt.Errorf("line %d: '%s' does not match the \"METHOD URL\" format", line, text)
+			}
+			t, err := NewTarget(parts[1], MethodOption(parts[0]))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %s", line, err)
+			}
+			t.ID = len(targets)
+			current = t
+			targets = append(targets, t)
+
+		default:
+			if current == nil {
+				return nil, fmt.Errorf("line %d: header '%s' does not belong to a target", line, text)
+			}
+			if err := HeaderOption(text)(current); err != nil {
+				return nil, fmt.Errorf("line %d: %s", line, err)
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return targets, nil
+}
+
+func isRequestLine(text string) bool {
+	method := strings.SplitN(text, " ", 2)[0]
+
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete,
+		http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+
+	return false
+}
+
+// jsonTarget is a target in the JSON-lines format
+type jsonTarget struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header"`
+	Body   string      `json:"body"`
+	Weight int         `json:"weight"`
+	Expect *jsonExpect `json:"expect"`
+}
+
+// jsonExpect are the validation rules of a target in the JSON-lines format
+type jsonExpect struct {
+	Status       []int                      `json:"status"`
+	BodyContains string                     `json:"body_contains"`
+	BodyRegex    string                     `json:"body_regex"`
+	JSON         map[string]json.RawMessage `json:"json"`
+	Headers      []string                   `json:"headers"`
+	MaxBodySize  int                        `json:"max_body_size"`
+}
+
+func (e *jsonExpect) rules() ([]Rule, error) {
+	var rules []Rule
+	if len(e.Status) != 0 {
+		rules = append(rules, ExpectStatus(e.Status...))
+	}
+	if e.BodyContains != "" {
+		rules = append(rules, ExpectBodyContains(e.BodyContains))
+	}
+	if e.BodyRegex != "" {
+		rule, err := ExpectBodyRegex(e.BodyRegex)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	paths := make([]string, 0, len(e.JSON))
+	for path := range e.JSON {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		rules = append(rules, ExpectJSON(path, string(e.JSON[path])))
+	}
+	for _, name := range e.Headers {
+		rules = append(rules, ExpectHeader(name))
+	}
+	if e.MaxBodySize > 0 {
+		rules = append(rules, MaxBodySize(e.MaxBodySize))
+	}
+
+	return rules, nil
+}
+
+// ReadJSONTargets reads targets in the JSON-lines format, one JSON object per line.
+//
+//	{"method": "POST", "url": "http://localhost:8080/users", "header": {"Content-Type": ["application/json"]}, "body": "{}", "weight": 2}
+//
+// Responses of a target may be validated with the rules under "expect":
+//
+//	{"url": "http://localhost:8080/users/1", "expect": {"status": [200], "body_contains": "id", "body_regex": "\\d+",
+//		"json": {"data.id": 1}, "headers": ["ETag"], "max_body_size": 1024}}
+func ReadJSONTargets(r io.Reader) ([]*Target, error) {
+	var targets []*Target
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+
+		var jt jsonTarget
+		if err := json.Unmarshal(scanner.Bytes(), &jt); err != nil {
+			return nil, fmt.Errorf("line %d: %s", line, err)
+		}
+
+		t, err := NewTarget(jt.URL, MethodOption(jt.Method), StringBodyOption(jt.Body))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", line, err)
+		}
+		t.Header = jt.Header
+		t.Weight = jt.Weight
+		t.ID = len(targets)
+		if jt.Expect != nil {
+			if t.Rules, err = jt.Expect.rules(); err != nil {
+				return nil, fmt.Errorf("line %d: %s", line, err)
+			}
+		}
+		targets = append(targets, t)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return targets, nil
+}
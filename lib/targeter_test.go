@@ -0,0 +1,161 @@
+package scurl
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRoundRobinTargeter(t *testing.T) {
+	first, _ := NewTarget("http://localhost/first")
+	second, _ := NewTarget("http://localhost/second")
+
+	targeter := NewRoundRobinTargeter(first, second)
+
+	for _, expected := range []*Target{first, second, first, second} {
+		actual, err := targeter.Next()
+		assert.Nil(t, err)
+		assert.Equal(t, expected, actual)
+	}
+}
+
+func TestEmptyTargeter(t *testing.T) {
+	_, err := NewRoundRobinTargeter().Next()
+	assert.Equal(t, ErrNoTargets, err)
+
+	_, err = NewRandomTargeter().Next()
+	assert.Equal(t, ErrNoTargets, err)
+}
+
+func TestWeightedTargeter(t *testing.T) {
+	light, _ := NewTarget("http://localhost/light")
+	heavy, _ := NewTarget("http://localhost/heavy")
+	heavy.Weight = 9
+
+	targeter := NewWeightedTargeter(light, heavy)
+
+	picks := map[string]int{}
+	for i := 0; i < 10000; i++ {
+		target, _ := targeter.Next()
+		picks[target.URL]++
+	}
+
+	assert.InDelta(t, 1000, picks[light.URL], 200)
+	assert.InDelta(t, 9000, picks[heavy.URL], 200)
+}
+
+func TestReadTextTargets(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "targets")
+	defer os.RemoveAll(dir)
+	bodyFile := filepath.Join(dir, "body.json")
+	_ = ioutil.WriteFile(bodyFile, []byte(`{"key":"val"}`), 0644)
+
+	input := `
+# users
+POST http://localhost:8080/users
+Content-Type: application/json
+@` + bodyFile + `
+
+GET http://localhost:8080/users
+X-Trace: 1
+Host: example.com:8080
+Referer: http://localhost:8080/
+
+PUT http://localhost:8080/users/1
+@body.json
+`
+
+	targets, err := ReadTextTargets(strings.NewReader(input), dir)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(targets))
+	assert.Equal(t, http.MethodPost, targets[0].Method)
+	assert.Equal(t, "application/json", targets[0].Header.Get("Content-Type"))
+	body, _ := ioutil.ReadAll(targets[0].getBody())
+	assert.Equal(t, `{"key":"val"}`, string(body))
+	assert.Equal(t, http.MethodGet, targets[1].Method)
+	assert.Equal(t, "http://localhost:8080/users", targets[1].URL)
+	assert.Nil(t, targets[1].Body)
+	assert.Equal(t, "example.com:8080", targets[1].Header.Get("Host"))
+	assert.Equal(t, "http://localhost:8080/", targets[1].Header.Get("Referer"))
+	// relative body paths are resolved against the directory of the targets file
+	body, _ = ioutil.ReadAll(targets[2].getBody())
+	assert.Equal(t, `{"key":"val"}`, string(body))
+}
+
+func TestReadTextTargetsWithHeaderWithoutTarget(t *testing.T) {
+	_, err := ReadTextTargets(strings.NewReader("Content-Type: application/json\nGET http://localhost"), "")
+
+	assert.NotNil(t, err)
+}
+
+func TestReadJSONTargets(t *testing.T) {
+	input := `{"method": "POST", "url": "http://localhost/a", "header": {"Content-Type": ["application/json"]}, "body": "{}", "weight": 3}
+
+{"url": "http://localhost/b"}
+`
+
+	targets, err := ReadJSONTargets(strings.NewReader(input))
+
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(targets))
+	assert.Equal(t, http.MethodPost, targets[0].Method)
+	assert.Equal(t, 3, targets[0].Weight)
+	assert.Equal(t, []string{"application/json"}, targets[0].Header["Content-Type"])
+	assert.Equal(t, http.MethodGet, targets[1].Method)
+}
+
+func TestReadJSONTargetsWithRules(t *testing.T) {
+	input := `{"url": "http://localhost/a", "expect": {"status": [200, 204], "body_contains": "ok", "json": {"data.id": 1}, "headers": ["ETag"], "max_body_size": 512}}`
+
+	targets, err := ReadJSONTargets(strings.NewReader(input))
+
+	assert.Nil(t, err)
+	rules := make([]string, len(targets[0].Rules))
+	for i, rule := range targets[0].Rules {
+		rules[i] = rule.String()
+	}
+	assert.Equal(t, []string{"status in [200 204]", `body contains "ok"`, "data.id == 1", "header ETag present", "body size <= 512"}, rules)
+
+	_, err = ReadJSONTargets(strings.NewReader(`{"url": "http://localhost/a", "expect": {"body_regex": "("}}`))
+	assert.NotNil(t, err)
+}
+
+func TestReadInvalidJSONTargets(t *testing.T) {
+	_, err := ReadJSONTargets(strings.NewReader(`{"url": `))
+
+	assert.NotNil(t, err)
+}
+
+func TestAttackMultipleTargets(t *testing.T) {
+	mu := sync.Mutex{}
+	paths := map[string]int{}
+
+	fs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths[r.URL.Path]++
+		mu.Unlock()
+	}))
+	defer fs.Close()
+
+	first, _ := NewTarget(fs.URL + "/first")
+	second, _ := NewTarget(fs.URL + "/second")
+
+	client := NewConcurrentClient(
+		FanOutOpt(1),
+		RateOpt(&Rate{Freq: 100, Per: time.Second}),
+		DurationOpt(100*time.Millisecond),
+	)
+
+	for range client.DoReq(NewRoundRobinTargeter(first, second)) {
+	}
+
+	assert.Equal(t, map[string]int{"/first": 5, "/second": 5}, paths)
+}
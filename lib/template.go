@@ -0,0 +1,305 @@
+package scurl
+
+import (
+	"crypto/rand"
+	"encoding/csv"
+	"fmt"
+	"io"
+	mrand "math/rand"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"text/template"
+	"time"
+)
+
+// TemplateOption renders the URL, the header values and the string body of the target as text/template
+// templates on every hit, which makes every request different. Other bodies, such as multipart forms, are sent
+// as they are. It has to be the last option of a target.
+//
+// Besides the builtins of text/template the following functions are available:
+//
+//	{{uuid}}                     a random UUID
+//	{{randInt 1 1000}}           a random integer in [1, 1000]
+//	{{seq}}                      the sequence number of the hit, starting at 1
+//	{{now}}                      the time of the hit, i.e. {{now.Unix}}
+//	{{csv "users.csv" "id"}}     the id column of the next row of users.csv, which starts with a header row
+//
+// The columns of the row a Feeder provides for the hit are available as {{.column}}.
+//
+// All the templates of a hit share the same sequence number, time and csv rows. The host of the URL cannot be
+// templated.
+func TemplateOption() ReqOption {
+	return func(req *Target) error {
+		tmpl, err := newRequestTemplate(req)
+		if err != nil {
+			return err
+		}
+
+		req.template = tmpl
+		return nil
+	}
+}
+
+// requestTemplate renders the requests of a templated target
+type requestTemplate struct {
+	set     *template.Template // holds the "url", "body" and "header N" templates
+	headers []templateHeader
+	body    bool
+
+	seq  uint64
+	csvs *csvFiles
+}
+
+type templateHeader struct {
+	key  string
+	name string // name of the template of the header value
+}
+
+// templateStubs declare the template functions at parse time, they are replaced by the functions of a hit
+var templateStubs = template.FuncMap{
+	"uuid":    func() string { return "" },
+	"randInt": func(min, max int) int { return 0 },
+	"seq":     func() uint64 { return 0 },
+	"now":     func() templateTime { return templateTime{} },
+	"csv":     func(path, column string) (string, error) { return "", nil },
+}
+
+func newRequestTemplate(t *Target) (*requestTemplate, error) {
+	tmpl := &requestTemplate{set: template.New("url").Funcs(templateStubs), csvs: &csvFiles{files: map[string]*csvFile{}}}
+
+	if _, err := tmpl.set.Parse(t.URL); err != nil {
+		return nil, fmt.Errorf("invalid url template: %s", err)
+	}
+	for key, values := range t.Header {
+		for _, v := range values {
+			name := fmt.Sprintf("header %d", len(tmpl.headers))
+			if _, err := tmpl.set.New(name).Parse(v); err != nil {
+				return nil, fmt.Errorf("invalid template of header %s: %s", key, err)
+			}
+			tmpl.headers = append(tmpl.headers, templateHeader{key: key, name: name})
+		}
+	}
+	if body, ok := t.Body.(*StringBody); ok {
+		if _, err := tmpl.set.New("body").Parse(body.value); err != nil {
+			return nil, fmt.Errorf("invalid body template: %s", err)
+		}
+		tmpl.body = true
+	}
+
+	// render a request upfront to report missing csv files and columns right away, the row of a feeder is not
+	// known yet and its columns render as "<no value>"
+	if _, err := tmpl.request(t, Row{}); err != nil {
+		return nil, err
+	}
+	tmpl.seq = 0
+	tmpl.csvs.rewind()
+
+	return tmpl, nil
+}
+
+// request renders the request of the next hit of t with the row of its feeder, which is nil without a feeder.
+func (tmpl *requestTemplate) request(t *Target, row Row) (*http.Request, error) {
+	set, err := tmpl.set.Clone()
+	if err != nil {
+		return nil, err
+	}
+	set.Funcs(tmpl.hit().funcs())
+
+	// the row of the upfront render is empty, columns missing from any other row fail the request
+	var data interface{}
+	if row != nil {
+		data = row
+	}
+	if row == nil || len(row) != 0 {
+		set.Option("missingkey=error")
+	}
+
+	var url strings.Builder
+	if err := set.ExecuteTemplate(&url, "url", data); err != nil {
+		return nil, err
+	}
+
+	var body io.Reader
+	if tmpl.body {
+		var b strings.Builder
+		if err := set.ExecuteTemplate(&b, "body", data); err != nil {
+			return nil, err
+		}
+		body = strings.NewReader(b.String())
+	} else {
+		body = t.getBody()
+	}
+
+	req, err := http.NewRequest(t.Method, url.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, h := range tmpl.headers {
+		var value strings.Builder
+		if err := set.ExecuteTemplate(&value, h.name, data); err != nil {
+			return nil, err
+		}
+		req.Header[h.key] = append(req.Header[h.key], value.String())
+	}
+
+	return req, nil
+}
+
+// templateHit holds the values shared by all the templates of a hit
+type templateHit struct {
+	tmpl *requestTemplate
+	seq  uint64
+	now  time.Time
+	rows map[string]int // csv row of the hit by file
+}
+
+func (tmpl *requestTemplate) hit() *templateHit {
+	return &templateHit{tmpl: tmpl, now: time.Now()}
+}
+
+func (h *templateHit) funcs() template.FuncMap {
+	return template.FuncMap{
+		"uuid":    newUUID,
+		"randInt": randInt,
+		"seq":     h.sequence,
+		"now":     func() templateTime { return templateTime{h.now} },
+		"csv":     h.csv,
+	}
+}
+
+func (h *templateHit) sequence() uint64 {
+	if h.seq == 0 {
+		h.seq = atomic.AddUint64(&h.tmpl.seq, 1)
+	}
+
+	return h.seq
+}
+
+func (h *templateHit) csv(path, column string) (string, error) {
+	file, err := h.tmpl.csvs.open(path)
+	if err != nil {
+		return "", err
+	}
+
+	if h.rows == nil {
+		h.rows = map[string]int{}
+	}
+	row, ok := h.rows[path]
+	if !ok {
+		row = file.next()
+		h.rows[path] = row
+	}
+
+	return file.value(row, column)
+}
+
+// templateTime prints as RFC 3339 in templates
+type templateTime struct {
+	time.Time
+}
+
+func (t templateTime) String() string {
+	return t.Format(time.RFC3339Nano)
+}
+
+func newUUID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40 // version 4
+	b[8] = b[8]&0x3f | 0x80 // variant 10
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+var (
+	randMu  sync.Mutex
+	randSrc = mrand.New(mrand.NewSource(time.Now().UnixNano()))
+)
+
+func randInt(min, max int) (int, error) {
+	if max < min {
+		return 0, fmt.Errorf("randInt: max %d is lower than min %d", max, min)
+	}
+
+	randMu.Lock()
+	defer randMu.Unlock()
+	return min + randSrc.Intn(max-min+1), nil
+}
+
+// csvFiles are the csv files of a template, loaded once they are first used
+type csvFiles struct {
+	mu    sync.Mutex
+	files map[string]*csvFile
+}
+
+func (c *csvFiles) open(path string) (*csvFile, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if file, ok := c.files[path]; ok {
+		return file, nil
+	}
+
+	file, err := readCSVFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c.files[path] = file
+	return file, nil
+}
+
+func (c *csvFiles) rewind() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, file := range c.files {
+		atomic.StoreUint64(&file.cursor, 0)
+	}
+}
+
+// csvFile is a csv file with a header row, its rows are used one after another, wrapping around at the end
+type csvFile struct {
+	path    string
+	columns map[string]int
+	rows    [][]string
+	cursor  uint64
+}
+
+func readCSVFile(path string) (*csvFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	if len(records) < 2 {
+		return nil, fmt.Errorf("%s: no rows after the header row", path)
+	}
+
+	file := &csvFile{path: path, columns: map[string]int{}, rows: records[1:]}
+	for i, name := range records[0] {
+		file.columns[strings.TrimSpace(name)] = i
+	}
+	return file, nil
+}
+
+func (f *csvFile) next() int {
+	return int((atomic.AddUint64(&f.cursor, 1) - 1) % uint64(len(f.rows)))
+}
+
+func (f *csvFile) value(row int, column string) (string, error) {
+	i, ok := f.columns[column]
+	if !ok {
+		return "", fmt.Errorf("%s: no column %q", f.path, column)
+	}
+
+	return f.rows[row][i], nil
+}
@@ -0,0 +1,97 @@
+package scurl
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+func TestRenderTemplatedTarget(t *testing.T) {
+	target, err := NewTarget("http://localhost/users/{{seq}}?id={{uuid}}",
+		MethodOption("POST"),
+		HeaderOption("X-Seq: {{seq}}"),
+		StringBodyOption(`{"n": {{randInt 5 7}}, "seq": {{seq}}, "at": {{now.Unix}}}`),
+		TemplateOption(),
+	)
+	assert.Nil(t, err)
+
+	for i := 1; i <= 3; i++ {
+		req, err := target.RequestWithContext(context.Background())
+		assert.Nil(t, err)
+
+		seq := strconv.Itoa(i)
+		assert.Equal(t, "POST", req.Method)
+		assert.Equal(t, "/users/"+seq, req.URL.Path)
+		assert.Regexp(t, regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`), req.URL.Query().Get("id"))
+		assert.Equal(t, []string{seq}, req.Header["X-Seq"])
+
+		body, _ := ioutil.ReadAll(req.Body)
+		assert.Regexp(t, regexp.MustCompile(`^\{"n": [5-7], "seq": `+seq+`, "at": \d+\}$`), string(body))
+	}
+}
+
+func TestRenderCSVTemplate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.csv")
+	assert.Nil(t, os.WriteFile(path, []byte("id,name\n1,ann\n2,bob\n"), 0644))
+
+	target, err := NewTarget("http://localhost/users/{{csv \""+path+"\" \"id\"}}",
+		HeaderOption(`X-Name: {{csv "`+path+`" "name"}}`),
+		TemplateOption(),
+	)
+	assert.Nil(t, err)
+
+	for _, expected := range [][]string{{"1", "ann"}, {"2", "bob"}, {"1", "ann"}} {
+		req, err := target.RequestWithContext(context.Background())
+		assert.Nil(t, err)
+		assert.Equal(t, "/users/"+expected[0], req.URL.Path)
+		assert.Equal(t, expected[1], req.Header.Get("X-Name"))
+	}
+}
+
+func TestSendFormOfTemplatedTarget(t *testing.T) {
+	type received struct{ path, name string }
+	requests := make(chan received, 2)
+	fs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests <- received{path: r.URL.Path, name: r.FormValue("name")}
+	}))
+	defer fs.Close()
+
+	target, err := NewTarget(fs.URL+"/users/{{.id}}",
+		MethodOption("POST"),
+		MultipartFormBodyOption(map[string]string{"name": "ann"}),
+		TemplateOption(),
+	)
+	assert.Nil(t, err)
+
+	for _, id := range []string{"1", "2"} {
+		// a feeder provides the row of every hit
+		hit := *target
+		hit.row = Row{"id": id}
+		req, err := hit.RequestWithContext(context.Background())
+		assert.Nil(t, err)
+
+		resp, err := NewTimedClient().Do(req)
+		assert.Nil(t, err)
+		resp.ReadAndDiscard()
+
+		assert.Equal(t, received{path: "/users/" + id, name: "ann"}, <-requests)
+	}
+}
+
+func TestInvalidTemplates(t *testing.T) {
+	_, err := NewTarget("http://localhost/{{seq", TemplateOption())
+	assert.NotNil(t, err)
+
+	_, err = NewTarget("http://localhost/{{csv \"missing.csv\" \"id\"}}", TemplateOption())
+	assert.NotNil(t, err)
+
+	_, err = NewTarget("http://localhost/{{randInt 5 1}}", TemplateOption())
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,216 @@
+package scurl
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Threshold is an assertion on the final metrics of an attack, such as "p99<300ms", "error_rate<1%",
+// "status_2xx>=99.5%" or "rps>=450".
+//
+// Supported metrics are the latency percentiles pNN (i.e. p50, p99.9) as well as mean, min and max, which are
+// compared to durations; error_rate and status_Nxx, which are percentages of all trips; and rps and trips.
+// Latencies are the response times corrected for coordinated omission, which include the time requests waited
+// behind their schedule, so that an overloaded target cannot meet an SLO its users do not see.
+type Threshold struct {
+	Metric string
+	Op     string
+	Value  float64 // durations in nanoseconds, percentages in percent
+	spec   string
+}
+
+// thresholdOps are the supported comparisons, longer ones first so that "<=" is not taken for "<"
+var thresholdOps = []string{"<=", ">=", "<", ">"}
+
+// ParseThreshold parses a threshold in the "METRIC OP VALUE" format, i.e. "p99<300ms".
+func ParseThreshold(spec string) (*Threshold, error) {
+	for _, op := range thresholdOps {
+		i := strings.Index(spec, op)
+		if i < 0 {
+			continue
+		}
+
+		t := &Threshold{Metric: strings.TrimSpace(spec[:i]), Op: op, spec: strings.TrimSpace(spec)}
+		value := strings.TrimSpace(spec[i+len(op):])
+
+		var err error
+		switch t.kind() {
+		case durationMetric:
+			var d time.Duration
+			d, err = time.ParseDuration(value)
+			t.Value = float64(d)
+		case percentMetric:
+			t.Value, err = strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+		case countMetric:
+			t.Value, err = strconv.ParseFloat(value, 64)
+		default:
+			return nil, fmt.Errorf("threshold '%s' has an unknown metric '%s'", spec, t.Metric)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("threshold '%s' has an invalid value '%s'", spec, value)
+		}
+
+		return t, nil
+	}
+
+	return nil, fmt.Errorf("threshold '%s' does not match the \"METRIC OP VALUE\" format (i.e. p99<300ms)", spec)
+}
+
+// ReadThresholds reads thresholds, one per line. Lines starting with '#' are comments.
+func ReadThresholds(r io.Reader) ([]*Threshold, error) {
+	var thresholds []*Threshold
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		t, err := ParseThreshold(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", line, err)
+		}
+		thresholds = append(thresholds, t)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return thresholds, nil
+}
+
+type metricKind int
+
+const (
+	unknownMetric metricKind = iota
+	durationMetric
+	percentMetric
+	countMetric
+)
+
+func (t *Threshold) kind() metricKind {
+	switch {
+	case t.Metric == "mean" || t.Metric == "min" || t.Metric == "max":
+		return durationMetric
+	case strings.HasPrefix(t.Metric, "p"):
+		if p, err := strconv.ParseFloat(t.Metric[1:], 64); err == nil && p > 0 && p <= 100 {
+			return durationMetric
+		}
+	case t.Metric == "error_rate":
+		return percentMetric
+	case strings.HasPrefix(t.Metric, "status_"):
+		if class := t.Metric[len("status_"):]; len(class) == 3 && class[0] >= '1' && class[0] <= '5' && class[1:] == "xx" {
+			return percentMetric
+		}
+	case t.Metric == "rps" || t.Metric == "trips":
+		return countMetric
+	}
+
+	return unknownMetric
+}
+
+// Actual returns the value of the metric of the threshold in m.
+func (t *Threshold) Actual(m *Metrics) float64 {
+	switch {
+	case t.Metric == "mean":
+		return float64(m.ResponseTimes.Mean())
+	case t.Metric == "min":
+		return float64(m.ResponseTimes.Min())
+	case t.Metric == "max":
+		return float64(m.ResponseTimes.Max())
+	case strings.HasPrefix(t.Metric, "p"):
+		p, _ := strconv.ParseFloat(t.Metric[1:], 64)
+		return float64(m.ResponseTimes.Percentile(p))
+	case t.Metric == "error_rate":
+		return percentOf(m.ErrorCount(), m.Trips)
+	case strings.HasPrefix(t.Metric, "status_"):
+		class, count := int(t.Metric[len("status_")]-'0'), 0
+		for code, c := range m.StatusCodes {
+			if code/100 == class {
+				count += c
+			}
+		}
+		return percentOf(count, m.Trips)
+	case t.Metric == "rps":
+		if elapsed := m.TotalTime(); elapsed > 0 {
+			return float64(m.Trips) / elapsed.Seconds()
+		}
+		return 0
+	case t.Metric == "trips":
+		return float64(m.Trips)
+	}
+
+	return 0
+}
+
+// Check reports whether m satisfies the threshold, along with the actual value of the metric. A metric without
+// samples, such as the latencies of an attack whose trips all failed, never satisfies a threshold.
+func (t *Threshold) Check(m *Metrics) (actual float64, ok bool) {
+	actual = t.Actual(m)
+	if !t.sampled(m) {
+		return actual, false
+	}
+
+	switch t.Op {
+	case "<":
+		return actual, actual < t.Value
+	case "<=":
+		return actual, actual <= t.Value
+	case ">":
+		return actual, actual > t.Value
+	case ">=":
+		return actual, actual >= t.Value
+	}
+
+	return actual, false
+}
+
+// sampled reports whether m has samples of the metric of the threshold. Latencies are only sampled from the trips
+// which did not fail, rates and the throughput need at least one trip.
+func (t *Threshold) sampled(m *Metrics) bool {
+	switch t.kind() {
+	case durationMetric:
+		return m.ResponseTimes.Count() != 0
+	case percentMetric:
+		return m.Trips != 0
+	}
+	if t.Metric == "rps" {
+		return m.Trips != 0
+	}
+
+	return true
+}
+
+// Format formats a value of the metric of the threshold, i.e. the actual value returned by Check.
+func (t *Threshold) Format(value float64) string {
+	switch t.kind() {
+	case durationMetric:
+		return time.Duration(value).String()
+	case percentMetric:
+		return strconv.FormatFloat(value, 'f', 2, 64) + "%"
+	case countMetric:
+		if t.Metric == "rps" {
+			return strconv.FormatFloat(value, 'f', 1, 64) + "/s"
+		}
+	}
+
+	return strconv.FormatFloat(value, 'f', 0, 64)
+}
+
+func (t *Threshold) String() string {
+	return t.spec
+}
+
+func percentOf(count, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+
+	return 100 * float64(count) / float64(total)
+}
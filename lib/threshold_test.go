@@ -0,0 +1,130 @@
+package scurl
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseThreshold(t *testing.T) {
+	th, err := ParseThreshold("p99<300ms")
+	assert.Nil(t, err)
+	assert.Equal(t, "p99", th.Metric)
+	assert.Equal(t, "<", th.Op)
+	assert.Equal(t, float64(300*time.Millisecond), th.Value)
+
+	th, err = ParseThreshold("status_2xx >= 99.5%")
+	assert.Nil(t, err)
+	assert.Equal(t, "status_2xx", th.Metric)
+	assert.Equal(t, ">=", th.Op)
+	assert.Equal(t, 99.5, th.Value)
+
+	th, err = ParseThreshold("rps>=450")
+	assert.Nil(t, err)
+	assert.Equal(t, 450.0, th.Value)
+
+	for _, spec := range []string{"p99", "p101<1s", "latency<1s", "p99<fast", "status_2x<1%", "error_rate<1s"} {
+		_, err := ParseThreshold(spec)
+		assert.NotNil(t, err, spec)
+	}
+}
+
+func TestCheckThresholds(t *testing.T) {
+	m := &Metrics{}
+	start := time.Now()
+	for i := 0; i < 100; i++ {
+		r := &Response{Response: &http.Response{StatusCode: http.StatusOK}, Time: time.Duration(i+1) * time.Millisecond,
+			Sent: start.Add(time.Duration(i) * 10 * time.Millisecond)}
+		if i < 2 {
+			r = &Response{Err: &TripError{Class: TimeoutError, Err: errors.New("timeout")}, Sent: r.Sent}
+		} else if i < 5 {
+			r.StatusCode = http.StatusInternalServerError
+		}
+		m.Add(r)
+	}
+
+	cases := map[string]bool{
+		"p50<60ms":        true,
+		"p99<=50ms":       false,
+		"max<=100ms":      true,
+		"error_rate<1%":   false,
+		"error_rate<=2%":  true,
+		"status_2xx>=95%": true,
+		"status_2xx>95%":  false,
+		"status_5xx<5%":   true,
+		"trips>=100":      true,
+		"rps>=90":         true,
+		"rps>100":         false,
+	}
+	for spec, expected := range cases {
+		th, err := ParseThreshold(spec)
+		assert.Nil(t, err, spec)
+
+		_, ok := th.Check(m)
+		assert.Equal(t, expected, ok, spec)
+	}
+}
+
+func TestCheckThresholdsOnResponseTimes(t *testing.T) {
+	m := &Metrics{}
+	start := time.Now()
+	for i := 0; i < 100; i++ {
+		// every request is served in 10ms but was sent 1s behind its schedule
+		m.Add(&Response{Response: &http.Response{StatusCode: http.StatusOK}, Time: 10 * time.Millisecond,
+			Sent: start.Add(time.Second), Intended: start, Late: true})
+	}
+
+	for _, spec := range []string{"p99<200ms", "mean<200ms", "max<200ms", "min<200ms"} {
+		th, _ := ParseThreshold(spec)
+
+		actual, ok := th.Check(m)
+		assert.False(t, ok, spec)
+		assert.Equal(t, float64(1010*time.Millisecond), actual, spec)
+	}
+}
+
+func TestFailThresholdsWithoutSamples(t *testing.T) {
+	failed := &Metrics{}
+	for i := 0; i < 10; i++ {
+		failed.Add(&Response{Err: &TripError{Class: TimeoutError, Err: errors.New("timeout")}, Sent: time.Now()})
+	}
+
+	for m, specs := range map[*Metrics][]string{
+		failed:       {"p99<300ms", "mean<1s", "max<=1s", "error_rate<1%"},
+		NewMetrics(): {"p99<300ms", "mean<1s", "error_rate<=100%", "status_5xx<1%", "rps>=0"},
+	} {
+		for _, spec := range specs {
+			th, _ := ParseThreshold(spec)
+
+			_, ok := th.Check(m)
+			assert.False(t, ok, spec)
+		}
+	}
+
+	trips, _ := ParseThreshold("trips<=10")
+	_, ok := trips.Check(failed)
+	assert.True(t, ok)
+}
+
+func TestFormatThresholdValues(t *testing.T) {
+	p99, _ := ParseThreshold("p99<300ms")
+	errorRate, _ := ParseThreshold("error_rate<1%")
+	rps, _ := ParseThreshold("rps>=450")
+
+	assert.Equal(t, "120ms", p99.Format(float64(120*time.Millisecond)))
+	assert.Equal(t, "0.50%", errorRate.Format(0.5))
+	assert.Equal(t, "460.3/s", rps.Format(460.26))
+	assert.Equal(t, "p99<300ms", p99.String())
+}
+
+func TestReadThresholds(t *testing.T) {
+	thresholds, err := ReadThresholds(strings.NewReader("# latency\np99<300ms\n\nerror_rate<1%\n"))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(thresholds))
+
+	_, err = ReadThresholds(strings.NewReader("p99<300ms\nfast\n"))
+	assert.EqualError(t, err, "line 2: threshold 'fast' does not match the \"METRIC OP VALUE\" format (i.e. p99<300ms)")
+}
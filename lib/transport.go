@@ -0,0 +1,120 @@
+package scurl
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+// TransportConfig describes the connection handling of the http client used to attack a target.
+type TransportConfig struct {
+	Timeout             time.Duration // Timeout of a whole trip including reading the response body, 0 means none
+	DialTimeout         time.Duration // Timeout for establishing a connection
+	KeepAlive           time.Duration // TCP keep-alive period of open connections
+	DisableKeepAlive    bool          // Open a new connection for every request instead of reusing connections
+	MaxIdleConns        int           // Maximum number of idle connections kept open per host
+	MaxConnsPerHost     int           // Maximum number of connections per host, 0 means unlimited
+	TLSHandshakeTimeout time.Duration // Timeout for the TLS handshake
+	Protocol            Protocol      // HTTP protocol to speak, negotiated if empty
+	TLSConfig           *tls.Config   // TLS configuration of the client such as trusted root CAs, the defaults if nil
+}
+
+// Protocol forces the HTTP protocol of the client.
+type Protocol string
+
+const (
+	NegotiatedProtocol Protocol = ""        // HTTP/2 if the server offers it over TLS, HTTP/1.1 otherwise
+	HTTP1              Protocol = "http1.1" // HTTP/1.1 only, even if the server offers HTTP/2 over TLS
+	HTTP2              Protocol = "http2"   // HTTP/2 over TLS only, requests of plain http URLs fail
+	H2C                Protocol = "h2c"     // HTTP/2 over cleartext TCP with prior knowledge, requests of https URLs fail
+	HTTP3              Protocol = "http3"   // HTTP/3 over QUIC, requests of plain http URLs fail
+)
+
+func (p Protocol) protocols() *http.Protocols {
+	protocols := &http.Protocols{}
+	switch p {
+	case HTTP1:
+		protocols.SetHTTP1(true)
+	case HTTP2:
+		protocols.SetHTTP2(true)
+	case H2C:
+		protocols.SetUnencryptedHTTP2(true)
+	default:
+		return nil
+	}
+
+	return protocols
+}
+
+// DefaultTransportConfig mirrors http.DefaultTransport, including the negotiation of HTTP/2 over TLS, except for
+// keeping more idle connections per host which lets concurrent attackers reuse their connections.
+var DefaultTransportConfig = TransportConfig{
+	DialTimeout:         30 * time.Second,
+	KeepAlive:           30 * time.Second,
+	MaxIdleConns:        100,
+	TLSHandshakeTimeout: 10 * time.Second,
+}
+
+// NewClient creates a Client with its own transport configured by cfg.
+func NewClient(cfg TransportConfig) *Client {
+	return &Client{Client: &http.Client{Timeout: cfg.Timeout, Transport: cfg.roundTripper()}, logger: mutedLogger}
+}
+
+func (cfg TransportConfig) roundTripper() http.RoundTripper {
+	if cfg.Protocol == HTTP3 {
+		return cfg.http3Transport()
+	}
+
+	return cfg.Protocol.enforce(cfg.transport())
+}
+
+// enforce makes the transport refuse the requests the forced protocol cannot be spoken for, which the transport
+// would send over HTTP/1.1 instead.
+func (p Protocol) enforce(t *http.Transport) http.RoundTripper {
+	switch p {
+	case HTTP2:
+		return &protocolTransport{Transport: t, protocol: p, scheme: "https"}
+	case H2C:
+		return &protocolTransport{Transport: t, protocol: p, scheme: "http"}
+	}
+
+	return t
+}
+
+// protocolTransport only sends the requests of a single scheme
+type protocolTransport struct {
+	*http.Transport
+	protocol Protocol
+	scheme   string
+}
+
+func (t *protocolTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	if r.URL.Scheme != t.scheme {
+		return nil, fmt.Errorf("unsupported protocol scheme %q, %s requires %s URLs", r.URL.Scheme, t.protocol, t.scheme)
+	}
+
+	return t.Transport.RoundTrip(r)
+}
+
+func (cfg TransportConfig) transport() *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   cfg.DialTimeout,
+		KeepAlive: cfg.KeepAlive,
+	}
+
+	return &http.Transport{
+		Protocols:             cfg.Protocol.protocols(),
+		ForceAttemptHTTP2:     true,
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		DisableKeepAlives:     cfg.DisableKeepAlive,
+		MaxIdleConnsPerHost:   cfg.MaxIdleConns,
+		MaxConnsPerHost:       cfg.MaxConnsPerHost,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   cfg.TLSHandshakeTimeout,
+		TLSClientConfig:       cfg.TLSConfig.Clone(),
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+}
@@ -0,0 +1,256 @@
+package scurl
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingServer starts a test server counting the connections opened by its clients
+func countingServer(handler http.HandlerFunc, conns *int32) *httptest.Server {
+	fs := httptest.NewUnstartedServer(handler)
+	fs.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(conns, 1)
+		}
+	}
+	fs.Start()
+
+	return fs
+}
+
+func attackSequentially(client *ConcurrentClient, url string) *Metrics {
+	req, _ := NewTarget(url)
+
+	m := NewMetrics()
+	for resp := range client.DoReq(req) {
+		resp.ReadAndDiscard()
+		m.Add(resp)
+	}
+
+	return m
+}
+
+func TestReuseConnections(t *testing.T) {
+	var conns int32
+	fs := countingServer(func(w http.ResponseWriter, r *http.Request) {}, &conns)
+	defer fs.Close()
+
+	client := NewConcurrentClient(
+		FanOutOpt(1),
+		RateOpt(&Rate{Freq: 20, Per: time.Second}),
+		DurationOpt(250*time.Millisecond),
+	)
+
+	m := attackSequentially(client, fs.URL)
+
+	assert.Equal(t, 5, m.Trips)
+	assert.True(t, atomic.LoadInt32(&conns) < 5, "connections are not reused")
+}
+
+func TestDisableKeepAliveOpensConnectionPerRequest(t *testing.T) {
+	var conns int32
+	fs := countingServer(func(w http.ResponseWriter, r *http.Request) {}, &conns)
+	defer fs.Close()
+
+	client := NewConcurrentClient(
+		FanOutOpt(1),
+		RateOpt(&Rate{Freq: 100, Per: time.Second}),
+		DurationOpt(100*time.Millisecond),
+		DisableKeepAliveOpt(true),
+	)
+
+	m := attackSequentially(client, fs.URL)
+
+	assert.Equal(t, 10, m.Trips)
+	assert.Equal(t, int32(10), atomic.LoadInt32(&conns))
+}
+
+func TestTimeoutOpt(t *testing.T) {
+	fs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+	}))
+	defer fs.Close()
+
+	client := NewConcurrentClient(
+		FanOutOpt(1),
+		RateOpt(&Rate{Freq: 10, Per: time.Second}),
+		DurationOpt(100*time.Millisecond),
+		TimeoutOpt(10*time.Millisecond),
+	)
+
+	m := attackSequentially(client, fs.URL)
+
+	assert.Equal(t, 1, m.Trips)
+	assert.Equal(t, map[ErrorClass]int{TimeoutError: 1}, m.Errors)
+}
+
+func TestTimeoutOptSparesWaitingResponses(t *testing.T) {
+	fs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(make([]byte, 1<<20))
+	}))
+	defer fs.Close()
+
+	client := NewConcurrentClient(
+		FanOutOpt(1),
+		RateOpt(&Rate{Freq: 20, Per: time.Second}),
+		DurationOpt(200*time.Millisecond),
+		TimeoutOpt(50*time.Millisecond),
+	)
+	target, _ := NewTarget(fs.URL)
+
+	m := NewMetrics()
+	for r := range client.DoReq(target) {
+		// a slow consumer, the responses were received in time and wait for it
+		time.Sleep(100 * time.Millisecond)
+		r.ReadAndDiscard()
+		m.Add(r)
+	}
+
+	assert.Equal(t, 4, m.Trips)
+	assert.Equal(t, 0, m.ErrorCount())
+	assert.Equal(t, uint64(4<<20), m.TotalBytes())
+}
+
+func TestNewClientConfiguresTransport(t *testing.T) {
+	cfg := DefaultTransportConfig
+	cfg.Timeout = time.Second
+	cfg.MaxIdleConns = 7
+	cfg.MaxConnsPerHost = 3
+
+	client := NewClient(cfg)
+
+	transport := client.Transport.(*http.Transport)
+	assert.Equal(t, time.Second, client.Timeout)
+	assert.Equal(t, 7, transport.MaxIdleConnsPerHost)
+	assert.Equal(t, 3, transport.MaxConnsPerHost)
+}
+
+// tlsClient creates a client with the given protocol trusting the certificate of the TLS test server
+func tlsClient(fs *httptest.Server, protocol Protocol) *Client {
+	cfg := DefaultTransportConfig
+	cfg.Protocol = protocol
+	cfg.TLSConfig = trustingConfig(fs)
+
+	return NewClient(cfg)
+}
+
+// trustingConfig returns a TLS config trusting the certificate of the TLS test server
+func trustingConfig(fs *httptest.Server) *tls.Config {
+	roots := x509.NewCertPool()
+	roots.AddCert(fs.Certificate())
+
+	return &tls.Config{RootCAs: roots}
+}
+
+func TestForceProtocolOverTLS(t *testing.T) {
+	fs := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	fs.EnableHTTP2 = true
+	fs.StartTLS()
+	defer fs.Close()
+
+	for protocol, expected := range map[Protocol]string{NegotiatedProtocol: "HTTP/2.0", HTTP1: "HTTP/1.1", HTTP2: "HTTP/2.0"} {
+		req, _ := http.NewRequest(http.MethodGet, fs.URL, nil)
+
+		resp, err := tlsClient(fs, protocol).Do(req)
+
+		if assert.Nil(t, err, string(protocol)) {
+			assert.Equal(t, expected, resp.Proto, string(protocol))
+			resp.ReadAndDiscard()
+		}
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, fs.URL, nil)
+	_, err := tlsClient(fs, H2C).Do(req)
+	assert.NotNil(t, err)
+}
+
+func TestH2CWithPriorKnowledge(t *testing.T) {
+	fs := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Proto))
+	}))
+	fs.Config.Protocols = &http.Protocols{}
+	fs.Config.Protocols.SetHTTP1(true)
+	fs.Config.Protocols.SetUnencryptedHTTP2(true)
+	fs.Start()
+	defer fs.Close()
+
+	client := NewConcurrentClient(
+		FanOutOpt(1),
+		RateOpt(&Rate{Freq: 20, Per: time.Second}),
+		DurationOpt(250*time.Millisecond),
+		ProtocolOpt(H2C),
+	)
+	m := attackSequentially(client, fs.URL)
+
+	assert.Equal(t, 5, m.Trips)
+	assert.Equal(t, map[string]int{"HTTP/2.0": 5}, m.Protocols)
+
+	client = NewConcurrentClient(
+		FanOutOpt(1),
+		RateOpt(&Rate{Freq: 20, Per: time.Second}),
+		DurationOpt(250*time.Millisecond),
+	)
+	m = attackSequentially(client, fs.URL)
+
+	assert.Equal(t, map[string]int{"HTTP/1.1": 5}, m.Protocols)
+
+	client = NewConcurrentClient(
+		FanOutOpt(1),
+		RateOpt(&Rate{Freq: 20, Per: time.Second}),
+		DurationOpt(250*time.Millisecond),
+		ProtocolOpt(HTTP2),
+	)
+	m = attackSequentially(client, fs.URL)
+
+	assert.Equal(t, map[ErrorClass]int{ProtocolError: 5}, m.Errors)
+}
+
+func TestMeasureTLSHandshakes(t *testing.T) {
+	fs := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer fs.Close()
+
+	client := tlsClient(fs, HTTP1)
+	for i, opened := range []bool{true, false} {
+		req, _ := http.NewRequest(http.MethodGet, fs.URL, nil)
+
+		resp, err := client.Do(req)
+
+		if assert.Nil(t, err) {
+			assert.Equal(t, opened, resp.Handshake > 0, i)
+			resp.ReadAndDiscard()
+		}
+	}
+}
+
+func TestCloseConnectionsAfterAttack(t *testing.T) {
+	var open int32
+	fs := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	fs.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		switch state {
+		case http.StateNew:
+			atomic.AddInt32(&open, 1)
+		case http.StateClosed, http.StateHijacked:
+			atomic.AddInt32(&open, -1)
+		}
+	}
+	fs.Start()
+	defer fs.Close()
+
+	client := NewConcurrentClient(
+		FanOutOpt(2),
+		RateOpt(&Rate{Freq: 20, Per: time.Second}),
+		DurationOpt(200*time.Millisecond),
+	)
+	attackSequentially(client, fs.URL)
+	assert.True(t, atomic.LoadInt32(&open) > 0)
+
+	assert.Nil(t, client.Close())
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&open) == 0 }, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,153 @@
+package scurl
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ThinkTime is the pause of a virtual user between two hits, drawn uniformly from [Mean-Jitter, Mean+Jitter].
+type ThinkTime struct {
+	Mean   time.Duration `json:"mean"`
+	Jitter time.Duration `json:"jitter"`
+}
+
+// ParseThinkTime parses a think time in the format "100ms", "100ms±50ms" or "100ms+-50ms".
+func ParseThinkTime(spec string) (ThinkTime, error) {
+	mean, jitter := spec, "0s"
+	for _, sep := range []string{"±", "+-"} {
+		if parts := strings.SplitN(spec, sep, 2); len(parts) == 2 {
+			mean, jitter = parts[0], parts[1]
+			break
+		}
+	}
+
+	var think ThinkTime
+	var err error
+	if think.Mean, err = time.ParseDuration(strings.TrimSpace(mean)); err != nil {
+		return think, fmt.Errorf("think time '%s' does not match the \"mean[±jitter]\" format (i.e. 100ms±50ms)", spec)
+	}
+	if think.Jitter, err = time.ParseDuration(strings.TrimSpace(jitter)); err != nil {
+		return think, fmt.Errorf("think time '%s' does not match the \"mean[±jitter]\" format (i.e. 100ms±50ms)", spec)
+	}
+	if think.Mean < 0 || think.Jitter < 0 || think.Jitter > think.Mean {
+		return think, fmt.Errorf("think time '%s' cannot be negative, the jitter cannot exceed the mean", spec)
+	}
+
+	return think, nil
+}
+
+// Next draws the next pause.
+func (t ThinkTime) Next() time.Duration {
+	if t.Jitter == 0 {
+		return t.Mean
+	}
+
+	randMu.Lock()
+	defer randMu.Unlock()
+	return t.Mean - t.Jitter + time.Duration(randSrc.Int63n(int64(2*t.Jitter)+1))
+}
+
+func (t ThinkTime) String() string {
+	if t.Jitter == 0 {
+		return t.Mean.String()
+	}
+
+	return fmt.Sprintf("%s±%s", t.Mean, t.Jitter)
+}
+
+// UsersOpt runs the attack in a closed loop of the given number of virtual users instead of at a rate. Every
+// user sends its next request as soon as the previous one completed and its think time passed, the throughput
+// is a result of the attack rather than its input. The users are split among the fan-out clients.
+func UsersOpt(num int) func(*ConcurrentClient) {
+	return func(client *ConcurrentClient) {
+		if num < 0 {
+			num = 0
+		}
+
+		client.users = num
+	}
+}
+
+// ThinkTimeOpt pauses every virtual user of a closed loop between its hits.
+func ThinkTimeOpt(think ThinkTime) func(*ConcurrentClient) {
+	return func(client *ConcurrentClient) {
+		client.think = think
+	}
+}
+
+// Loop runs the given number of virtual users, each hitting the targets one after another, until the duration
+// passed or the attack was stopped. A duration of 0 means forever.
+func (a *attacker) Loop(t Targeter, users int, think ThinkTime, du time.Duration) <-chan *Response {
+	workers := sync.WaitGroup{}
+	results := make(chan *Response)
+	a.init()
+
+	expired := make(chan struct{})
+	var timer *time.Timer
+	if du > 0 {
+		timer = time.AfterFunc(du, func() { close(expired) })
+	}
+
+	for i := 0; i < users; i++ {
+		workers.Add(1)
+		go a.user(t, think, expired, &workers, results)
+	}
+
+	go func() {
+		defer close(results)
+		workers.Wait()
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+
+	return results
+}
+
+func (a *attacker) user(t Targeter, think ThinkTime, expired <-chan struct{}, workers *sync.WaitGroup, results chan *Response) {
+	defer workers.Done()
+	if a.active != nil {
+		atomic.AddInt64(a.active, 1)
+		defer atomic.AddInt64(a.active, -1)
+	}
+
+	client := a.workerClient()
+	timer := time.NewTimer(0)
+	if !timer.Stop() {
+		<-timer.C
+	}
+
+	for {
+		select {
+		case <-a.Done():
+			return
+		case <-a.stopper.Ended():
+			return
+		case <-expired:
+			return
+		default:
+		}
+
+		if a.scenario != nil {
+			a.play(client, a.scenario, tick{}, results)
+		} else if resp := a.hit(client, t, tick{}); resp != nil {
+			results <- resp
+		}
+
+		if pause := think.Next(); pause > 0 {
+			timer.Reset(pause)
+			select {
+			case <-timer.C:
+			case <-a.Done():
+				return
+			case <-a.stopper.Ended():
+				return
+			case <-expired:
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package scurl
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParseThinkTime(t *testing.T) {
+	for spec, expected := range map[string]ThinkTime{
+		"100ms":       {Mean: 100 * time.Millisecond},
+		"100ms±50ms":  {Mean: 100 * time.Millisecond, Jitter: 50 * time.Millisecond},
+		"1s +- 250ms": {Mean: time.Second, Jitter: 250 * time.Millisecond},
+		"0s":          {},
+	} {
+		think, err := ParseThinkTime(spec)
+		assert.Nil(t, err, spec)
+		assert.Equal(t, expected, think, spec)
+	}
+
+	for _, spec := range []string{"", "fast", "100ms±", "100ms±200ms", "-1s"} {
+		_, err := ParseThinkTime(spec)
+		assert.NotNil(t, err, spec)
+	}
+
+	assert.Equal(t, "100ms±50ms", ThinkTime{Mean: 100 * time.Millisecond, Jitter: 50 * time.Millisecond}.String())
+}
+
+func TestThinkTimeWithinJitter(t *testing.T) {
+	think := ThinkTime{Mean: 100 * time.Millisecond, Jitter: 50 * time.Millisecond}
+
+	for i := 0; i < 1000; i++ {
+		pause := think.Next()
+		assert.True(t, pause >= 50*time.Millisecond && pause <= 150*time.Millisecond, pause)
+	}
+	assert.Equal(t, time.Second, ThinkTime{Mean: time.Second}.Next())
+}
+
+func TestClosedLoopKeepsUsersBusy(t *testing.T) {
+	var concurrent, maxConcurrent int64
+	fs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt64(&concurrent, 1)
+		defer atomic.AddInt64(&concurrent, -1)
+		for {
+			max := atomic.LoadInt64(&maxConcurrent)
+			if n <= max || atomic.CompareAndSwapInt64(&maxConcurrent, max, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}))
+	defer fs.Close()
+
+	client := NewConcurrentClient(
+		FanOutOpt(2),
+		UsersOpt(5),
+		DurationOpt(300*time.Millisecond),
+	)
+	target, _ := NewTarget(fs.URL)
+
+	trips := 0
+	for r := range client.DoReq(target) {
+		assert.Nil(t, r.Err)
+		assert.True(t, r.Intended.IsZero())
+		trips++
+	}
+
+	assert.Equal(t, int64(5), atomic.LoadInt64(&maxConcurrent))
+	assert.True(t, trips > 50, trips)
+	assert.Equal(t, 5, client.Users())
+	assert.Equal(t, 0.0, client.HitsPerSecond())
+	assert.Equal(t, int64(0), client.Workers())
+}
+
+func TestClosedLoopThinks(t *testing.T) {
+	fs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer fs.Close()
+
+	client := NewConcurrentClient(
+		FanOutOpt(1),
+		UsersOpt(1),
+		ThinkTimeOpt(ThinkTime{Mean: 50 * time.Millisecond}),
+		DurationOpt(275*time.Millisecond),
+	)
+	target, _ := NewTarget(fs.URL)
+
+	trips := 0
+	for range client.DoReq(target) {
+		trips++
+	}
+
+	assert.Equal(t, 6, trips)
+}
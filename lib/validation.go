@@ -0,0 +1,264 @@
+package scurl
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Rule validates the responses of a target. A response which violates a rule is not a failed trip, it is counted
+// as a validation failure separately from the transport errors.
+type Rule interface {
+	// Validate returns an error describing how the response with the given body violates the rule.
+	Validate(resp *http.Response, body []byte) error
+	String() string
+}
+
+// ValidationError is the violation of a Rule by a response.
+type ValidationError struct {
+	Rule Rule
+	Err  error
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Rule, e.Err)
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// ExpectOption validates every response of the target with the given rules.
+func ExpectOption(rules ...Rule) ReqOption {
+	return func(req *Target) error {
+		req.Rules = append(req.Rules, rules...)
+		return nil
+	}
+}
+
+// validate returns the violation of the first rule of the target the response does not satisfy.
+func (t *Target) validate(resp *http.Response, body []byte) *ValidationError {
+	for _, rule := range t.Rules {
+		if err := rule.Validate(resp, body); err != nil {
+			return &ValidationError{Rule: rule, Err: err}
+		}
+	}
+
+	return nil
+}
+
+type statusRule struct {
+	codes []int
+}
+
+// ExpectStatus requires the status code of the response to be one of the given codes.
+func ExpectStatus(codes ...int) Rule {
+	sorted := append([]int{}, codes...)
+	sort.Ints(sorted)
+
+	return &statusRule{codes: sorted}
+}
+
+func (r *statusRule) Validate(resp *http.Response, _ []byte) error {
+	for _, code := range r.codes {
+		if resp.StatusCode == code {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unexpected status %d", resp.StatusCode)
+}
+
+func (r *statusRule) String() string {
+	codes := make([]string, len(r.codes))
+	for i, code := range r.codes {
+		codes[i] = strconv.Itoa(code)
+	}
+
+	return "status in [" + strings.Join(codes, " ") + "]"
+}
+
+type bodyContainsRule struct {
+	text string
+}
+
+// ExpectBodyContains requires the body of the response to contain the given text.
+func ExpectBodyContains(text string) Rule {
+	return &bodyContainsRule{text: text}
+}
+
+func (r *bodyContainsRule) Validate(_ *http.Response, body []byte) error {
+	if !bytes.Contains(body, []byte(r.text)) {
+		return fmt.Errorf("body does not contain %q", r.text)
+	}
+
+	return nil
+}
+
+func (r *bodyContainsRule) String() string {
+	return fmt.Sprintf("body contains %q", r.text)
+}
+
+type bodyRegexRule struct {
+	re *regexp.Regexp
+}
+
+// ExpectBodyRegex requires the body of the response to match the given regular expression.
+func ExpectBodyRegex(expr string) (Rule, error) {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid body regex '%s': %s", expr, err)
+	}
+
+	return &bodyRegexRule{re: re}, nil
+}
+
+func (r *bodyRegexRule) Validate(_ *http.Response, body []byte) error {
+	if !r.re.Match(body) {
+		return fmt.Errorf("body does not match %q", r.re)
+	}
+
+	return nil
+}
+
+func (r *bodyRegexRule) String() string {
+	return fmt.Sprintf("body matches %q", r.re)
+}
+
+type jsonRule struct {
+	path     string
+	keys     []string
+	expected interface{}
+}
+
+// ExpectJSON requires the value at the given path of the JSON body to equal the expected value. The path
+// consists of object keys and array indices separated by dots, i.e. "data.items.0.id", and may start with "$.".
+// The expected value is compared as JSON, i.e. "42", "true" or "\"ok\"", and as a plain string if it is not
+// valid JSON.
+func ExpectJSON(path, expected string) Rule {
+	rule := &jsonRule{path: path, keys: jsonPathKeys(path), expected: expected}
+
+	var value interface{}
+	if err := json.Unmarshal([]byte(expected), &value); err == nil {
+		rule.expected = value
+	}
+
+	return rule
+}
+
+func jsonPathKeys(path string) []string {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	path = strings.NewReplacer("[", ".", "]", "").Replace(path)
+	if path == "" {
+		return nil
+	}
+
+	return strings.Split(path, ".")
+}
+
+func (r *jsonRule) Validate(_ *http.Response, body []byte) error {
+	value, err := jsonLookup(body, r.path, r.keys)
+	if err != nil {
+		return err
+	}
+
+	if !reflect.DeepEqual(value, r.expected) {
+		actual, _ := json.Marshal(value)
+		return fmt.Errorf("%s is %s", r.path, actual)
+	}
+
+	return nil
+}
+
+// jsonLookup returns the value at the path of the JSON body, split into its keys.
+func jsonLookup(body []byte, path string, keys []string) (interface{}, error) {
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return nil, fmt.Errorf("body is not valid JSON: %s", err)
+	}
+
+	for _, key := range keys {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			field, ok := v[key]
+			if !ok {
+				return nil, fmt.Errorf("%s not found", path)
+			}
+			value = field
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, fmt.Errorf("%s not found", path)
+			}
+			value = v[i]
+		default:
+			return nil, fmt.Errorf("%s not found", path)
+		}
+	}
+
+	return value, nil
+}
+
+func (r *jsonRule) String() string {
+	expected, _ := json.Marshal(r.expected)
+	return fmt.Sprintf("%s == %s", r.path, expected)
+}
+
+type headerRule struct {
+	name string
+}
+
+// ExpectHeader requires the response to have the given header.
+func ExpectHeader(name string) Rule {
+	return &headerRule{name: name}
+}
+
+func (r *headerRule) Validate(resp *http.Response, _ []byte) error {
+	if _, ok := resp.Header[http.CanonicalHeaderKey(r.name)]; !ok {
+		return fmt.Errorf("header %s is missing", r.name)
+	}
+
+	return nil
+}
+
+func (r *headerRule) String() string {
+	return fmt.Sprintf("header %s present", r.name)
+}
+
+type maxBodySizeRule struct {
+	size int
+}
+
+// MaxBodySize limits the size of the response body in bytes.
+func MaxBodySize(size int) Rule {
+	return &maxBodySizeRule{size: size}
+}
+
+func (r *maxBodySizeRule) Validate(_ *http.Response, body []byte) error {
+	if len(body) > r.size {
+		return fmt.Errorf("body of %d bytes exceeds %d bytes", len(body), r.size)
+	}
+
+	return nil
+}
+
+func (r *maxBodySizeRule) String() string {
+	return fmt.Sprintf("body size <= %d", r.size)
+}
+
+// recordedRule is a rule read back from a results file, it only knows its description
+type recordedRule string
+
+func (r recordedRule) Validate(*http.Response, []byte) error {
+	return nil
+}
+
+func (r recordedRule) String() string {
+	return string(r)
+}
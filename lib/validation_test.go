@@ -0,0 +1,103 @@
+package scurl
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidationRules(t *testing.T) {
+	resp := &http.Response{StatusCode: http.StatusCreated, Header: http.Header{"Etag": {"abc"}}}
+	body := []byte(`{"data": {"id": 42, "name": "scurl", "tags": ["load", "test"]}, "ok": true}`)
+
+	regex, err := ExpectBodyRegex(`"id": \d+`)
+	assert.Nil(t, err)
+
+	valid := []Rule{
+		ExpectStatus(http.StatusOK, http.StatusCreated),
+		ExpectBodyContains("scurl"),
+		regex,
+		ExpectJSON("data.id", "42"),
+		ExpectJSON("$.data.name", "scurl"),
+		ExpectJSON("data.tags[1]", `"test"`),
+		ExpectJSON("ok", "true"),
+		ExpectHeader("ETag"),
+		MaxBodySize(len(body)),
+	}
+	for _, rule := range valid {
+		assert.Nil(t, rule.Validate(resp, body), rule.String())
+	}
+
+	invalid := []Rule{
+		ExpectStatus(http.StatusOK),
+		ExpectBodyContains("vegeta"),
+		ExpectJSON("data.id", "43"),
+		ExpectJSON("data.missing", "1"),
+		ExpectJSON("data.tags.2", "1"),
+		ExpectHeader("X-Request-Id"),
+		MaxBodySize(10),
+	}
+	for _, rule := range invalid {
+		assert.NotNil(t, rule.Validate(resp, body), rule.String())
+	}
+
+	assert.NotNil(t, ExpectJSON("ok", "true").Validate(resp, []byte("<html>")))
+
+	_, err = ExpectBodyRegex("(")
+	assert.NotNil(t, err)
+}
+
+func TestDescribeValidationRules(t *testing.T) {
+	regex, _ := ExpectBodyRegex(`\d+`)
+
+	assert.Equal(t, "status in [200 201]", ExpectStatus(201, 200).String())
+	assert.Equal(t, `body contains "ok"`, ExpectBodyContains("ok").String())
+	assert.Equal(t, `body matches "\\d+"`, regex.String())
+	assert.Equal(t, `data.id == 42`, ExpectJSON("data.id", "42").String())
+	assert.Equal(t, `data.name == "scurl"`, ExpectJSON("data.name", "scurl").String())
+	assert.Equal(t, "header ETag present", ExpectHeader("ETag").String())
+	assert.Equal(t, "body size <= 10", MaxBodySize(10).String())
+}
+
+func TestValidateResponsesOfTarget(t *testing.T) {
+	fs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/broken" {
+			w.Write([]byte(`{"ok": false}`))
+			return
+		}
+		w.Write([]byte(`{"ok": true}`))
+	}))
+	defer fs.Close()
+
+	m := NewMetrics()
+	for _, path := range []string{"/", "/broken", "/"} {
+		target, _ := NewTarget(fs.URL+path, ExpectOption(ExpectStatus(http.StatusOK), ExpectJSON("ok", "true")))
+		req, _ := target.RequestWithContext(NewStopper().ctx)
+
+		resp, err := (&Client{Client: http.DefaultClient, logger: mutedLogger}).Do(req)
+		assert.Nil(t, err)
+		resp.Target = target
+		resp.ReadAndDiscard()
+		m.Add(resp)
+	}
+
+	assert.Equal(t, 3, m.Trips)
+	assert.Equal(t, 0, m.ErrorCount())
+	assert.Equal(t, map[int]int{http.StatusOK: 3}, m.StatusCodes)
+	assert.Equal(t, map[string]int{"ok == true": 1}, m.Invalid)
+	assert.Equal(t, 1, m.InvalidCount())
+}
+
+func TestReadAndDiscardWithoutTarget(t *testing.T) {
+	resp := &Response{Response: &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(strings.NewReader("ok"))},
+		Time: time.Millisecond}
+
+	resp.ReadAndDiscard()
+
+	assert.Nil(t, resp.Invalid)
+	assert.Equal(t, 2, resp.TotalBytes)
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"github.com/newestuser/scurl/lib"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// liveRefresh is how often the live dashboard is redrawn
+const liveRefresh = time.Second
+
+// liveWindow is the number of refreshes the rolling latencies and error rate are computed over
+const liveWindow = 5
+
+// dashboard renders the progress of a running stress to a terminal, redrawing itself in place.
+type dashboard struct {
+	w       io.Writer
+	client  *scurl.ConcurrentClient
+	began   time.Time
+	total   *scurl.Metrics
+	windows []*scurl.Metrics // metrics of the last refreshes, the last one being the current
+	lines   int              // number of lines drawn last time
+}
+
+func newDashboard(w io.Writer, client *scurl.ConcurrentClient) *dashboard {
+	return &dashboard{
+		w:       w,
+		client:  client,
+		began:   time.Now(),
+		total:   scurl.NewMetrics(),
+		windows: []*scurl.Metrics{scurl.NewMetrics()},
+	}
+}
+
+func (d *dashboard) add(r *scurl.Response) {
+	d.total.Add(r)
+	d.windows[len(d.windows)-1].Add(r)
+}
+
+// draw redraws the dashboard and starts a new refresh window.
+func (d *dashboard) draw() {
+	current := d.windows[len(d.windows)-1]
+	rolling := &scurl.Metrics{}
+	for _, m := range d.windows {
+		rolling.Merge(m)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Elapsed:   %s\n", time.Since(d.began).Round(time.Second))
+	if users := d.client.Users(); users > 0 {
+		fmt.Fprintf(&b, "Rate:      %.1f/s (%d users)\n", float64(current.Trips)/liveRefresh.Seconds(), users)
+	} else if d.client.Unpaced() {
+		fmt.Fprintf(&b, "Rate:      %.1f/s (target max)\n", float64(current.Trips)/liveRefresh.Seconds())
+	} else {
+		fmt.Fprintf(&b, "Rate:      %.1f/s (target %.1f/s)\n", float64(current.Trips)/liveRefresh.Seconds(), d.client.HitsPerSecond())
+	}
+	fmt.Fprintf(&b, "In flight: %d\n", d.client.InFlight())
+	fmt.Fprintf(&b, "Latency:   p50 %s, p99 %s (last %s)\n",
+		rolling.Percentile(50), rolling.Percentile(99), liveRefresh*time.Duration(len(d.windows)))
+	fmt.Fprintf(&b, "Errors:    %.2f%% (last %s), %d total\n",
+		errorRate(rolling), liveRefresh*time.Duration(len(d.windows)), d.total.ErrorCount())
+	fmt.Fprintf(&b, "Trips:     %d\n", d.total.Trips)
+
+	codes := make([]int, 0, len(d.total.StatusCodes))
+	for code := range d.total.StatusCodes {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+	for _, code := range codes {
+		fmt.Fprintf(&b, "  %d: %d\n", code, d.total.StatusCodes[code])
+	}
+
+	// move the cursor back to the first line drawn last time and clear everything below it
+	if d.lines > 0 {
+		fmt.Fprintf(d.w, "\033[%dA\033[J", d.lines)
+	}
+	fmt.Fprint(d.w, b.String())
+	d.lines = strings.Count(b.String(), "\n")
+
+	d.windows = append(d.windows, scurl.NewMetrics())
+	if len(d.windows) > liveWindow {
+		d.windows = d.windows[1:]
+	}
+}
+
+// errorRate returns the percentage of failed trips.
+func errorRate(m *scurl.Metrics) float64 {
+	if m.Trips == 0 {
+		return 0
+	}
+
+	return 100 * float64(m.ErrorCount()) / float64(m.Trips)
+}
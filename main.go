@@ -4,6 +4,7 @@ import (
 	"flag"
 	"fmt"
 	"github.com/newestuser/scurl/lib"
+	"io"
 	"log"
 	"net/http"
 	"os"
@@ -17,29 +18,43 @@ import (
 const Version = "0.6"
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "report" {
+		if err := reportCmd(os.Args[2:]); err != nil {
+			log.Fatal(err.Error())
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "search" {
+		if err := searchCmd(os.Args[2:]); err != nil {
+			log.Fatal(err.Error())
+		}
+		return
+	}
+
 	fs := flag.NewFlagSet("scurl", flag.ExitOnError)
 
 	version := fs.Bool("version", false, "Print version and exit")
 
-	opts := &reqOpts{
-		headers: headers{make([]string, 0)},
-		method:  methodFlag{},
-		rate:    rateFlag{scurl.DefaultRate},
-		verbose: false,
-		form:    multipartForm{map[string]string{}},
-	}
-
-	fs.IntVar(&opts.fanOut, "fo", 1, "Fan out factor is the number of clients to spawn")
-	fs.Var(&opts.rate, "rate", "Rate of the requests to be send by the client (i.e. 50/1s)")
+	opts := newReqOpts()
+	opts.register(fs)
+	fs.Var(&opts.rate, "rate", "Rate of the requests to be send by the client (i.e. 50/1s), max or 0 sends them as fast as the workers allow")
+	fs.Var(&opts.profile, "profile", "Load profile overriding the constant -rate, rates are in requests per second (i.e. linear:10:500:2m)\n"+profileFormats)
+	fs.IntVar(&opts.users, "users", 0, "Number of virtual users of a closed loop sending requests one after another, overrides -rate and -profile [0 = open loop at -rate] (default 0)")
+	fs.Var(&opts.think, "think", "Think time of the -users between their requests, uniform within the jitter (i.e. 100ms±50ms)")
 	fs.DurationVar(&opts.duration, "duration", 0, "Duration of stress [0 = forever] (i.e. 1m) (default 0)")
-	fs.Var(&opts.method, "X", "HTTP method to use (default GET)")
-	fs.Var(&opts.headers, "H", "HTTP header to add")
-	fs.StringVar(&opts.body, "d", "", "HTTP body to transport")
-	fs.Var(&opts.form, "F", "Add form-data in the format [key=value] (Content-Type is set to multipart/form-data)")
-	fs.BoolVar(&opts.verbose, "verbose", false, "Verbose logging")
+	fs.DurationVar(&opts.every, "every", 0, "Print a summary of every interval of the given length to stdout, or to stderr when the JSON report goes to stdout [0 = never] (i.e. 10s) (default 0)")
+	fs.StringVar(&opts.metricsAddr, "metrics-addr", "", "Address to serve live metrics on in the Prometheus format at /metrics (i.e. :9100)")
+	fs.BoolVar(&opts.live, "live", false, "Show a live dashboard of the stress on stderr, refreshed every second")
+	fs.Var(&opts.thresholds, "threshold", "Threshold the final metrics have to satisfy or scurl exits with an error, may be repeated (i.e. p99<300ms, error_rate<1%, status_2xx>=99.5%, rps>=450)")
+	fs.StringVar(&opts.thresholdsFile, "thresholds", "", "File with one -threshold per line")
+	fs.StringVar(&opts.record, "record", "", "File to record every result to, which 'scurl report' turns into a report later")
 
 	fs.Usage = func() {
 		fmt.Println("Usage: scurl [global flags] '<url>'")
+		fmt.Println("       scurl [global flags] -targets <file>")
+		fmt.Println("       scurl [global flags] -scenario <file>")
+		fmt.Println("       scurl search [search flags] '<url>'")
+		fmt.Println("       scurl report [report flags] <results file>...")
 		fmt.Printf("\nglobal flags:\n")
 		fs.PrintDefaults()
 		fmt.Print(example)
@@ -56,81 +71,152 @@ func main() {
 		return
 	}
 
-	if len(fs.Args()) != 1 {
+	if !opts.validArgs(fs.Args()) {
 		fs.Usage()
 		os.Exit(1)
 	}
+	if err := opts.validFlags(fs); err != nil {
+		log.Fatal(err)
+	}
+
+	if opts.thresholdsFile != "" {
+		if err := opts.thresholds.read(opts.thresholdsFile); err != nil {
+			log.Fatal(err.Error())
+		}
+	}
 
 	runtime.GOMAXPROCS(runtime.NumCPU())
 
-	if e := stress(fs.Args()[0], opts); e != nil {
+	if e := stress(fs.Args(), opts); e != nil {
 		log.Fatal(e.Error())
 	}
 
 }
 
-func stress(target string, opts *reqOpts) error {
-	bodyOption, err := opts.bodyOption()
+func stress(args []string, opts *reqOpts) (err error) {
+	src, params, err := opts.source(args)
 	if err != nil {
 		return err
 	}
 
-	request, e := scurl.NewTarget(target,
-		scurl.MethodOption(opts.method.verb),
-		bodyOption,
-		scurl.HeaderOption(opts.headers.headers...),
-	)
+	client := scurl.NewConcurrentClient(append(opts.clientOpts(),
+		scurl.PacerOpt(opts.pacer()),
+		scurl.UsersOpt(opts.users),
+		scurl.ThinkTimeOpt(opts.think.val),
+		scurl.DurationOpt(opts.duration),
+	)...)
 
-	if e != nil {
-		return e
+	out, err := createOutput(opts.outputFile)
+	if err != nil {
+		return err
 	}
+	defer closeOutput(out, &err)
 
-	client := scurl.NewConcurrentClient(
-		scurl.FanOutOpt(opts.fanOut),
-		scurl.RateOpt(opts.rate.val),
-		scurl.DurationOpt(opts.duration),
-		scurl.VerboseOpt(opts.verbose),
-	)
+	var recorder *scurl.Recorder
+	if opts.record != "" {
+		f, e := os.Create(opts.record)
+		if e != nil {
+			return e
+		}
+		if recorder, e = scurl.NewRecorder(f, params); e != nil {
+			f.Close()
+			return e
+		}
+		defer func() {
+			if e := closeRecording(recorder, f); e != nil && err == nil {
+				err = e
+			}
+		}()
+	}
+
+	res := src.attack(client)
 
-	res := client.DoReq(request)
+	var exp *exporter
+	if opts.metricsAddr != "" {
+		if exp, err = serveMetrics(opts.metricsAddr, client); err != nil {
+			client.Stop()
+			return err
+		}
+	}
 
 	sig := make(chan os.Signal, 1)
 	signal.Notify(sig, os.Interrupt)
 
-	concurrentResp := &scurl.MultiResponse{StartTime: time.Now()}
+	var live *dashboard
+	var refresh <-chan time.Time
+	if opts.live {
+		live = newDashboard(os.Stderr, client)
+		ticker := time.NewTicker(liveRefresh)
+		defer ticker.Stop()
+		refresh = ticker.C
+	}
+
+	metrics := scurl.NewMetrics()
+
+	var interval *intervalReport
+	var intervalEnd <-chan time.Time
+	if opts.every > 0 {
+		// the summaries are kept apart from a JSON report on stdout, which remains parsable
+		var w io.Writer = os.Stdout
+		if opts.output.format == "json" && opts.outputFile == "" {
+			w = os.Stderr
+		}
+		interval = newIntervalReport(w, metrics)
+		ticker := time.NewTicker(opts.every)
+		defer ticker.Stop()
+		intervalEnd = ticker.C
+	}
+
 	for {
 		select {
 		case <-sig:
 			client.Stop()
-			printResult(concurrentResp)
-			return nil
+			return opts.finish(out, params, metrics)
+		case <-refresh:
+			live.draw()
+		case <-intervalEnd:
+			interval.print()
 		case r, ok := <-res:
 
 			if !ok {
-				printResult(concurrentResp)
-				return nil
+				err := opts.finish(out, params, metrics)
+				if e := client.Err(); e != nil {
+					return fmt.Errorf("stress ended early: %s", e)
+				}
+				return err
 			}
 
-			r.ReadAndDiscard()
-			concurrentResp.Add(r)
+			metrics.Add(r)
+			if live != nil {
+				live.add(r)
+			}
+			if interval != nil {
+				interval.add(r)
+			}
+			if exp != nil {
+				exp.add(r)
+			}
+			if recorder != nil {
+				if err := recorder.Record(r); err != nil {
+					client.Stop()
+					return err
+				}
+			}
 		}
 	}
 }
 
-func printResult(resp *scurl.MultiResponse) {
-	fmt.Println("Trips:", resp.Trips)
-	if !resp.Empty() {
-		fmt.Println("Total time:", resp.TotalTime())
-		fmt.Println("Avr time:", resp.AvrTime())
-		fmt.Println("Fastest:", resp.Fastest().Time)
-		fmt.Println("Slowest:", resp.Slowest().Time)
-		fmt.Println("Total bytes:", resp.TotalBites())
-
-		statMap := resp.StatusMap()
-		for status, resps := range statMap {
-			fmt.Printf("\tStatus %d: %d responses\n", status, len(resps))
-		}
+// closeRecording flushes the recorded results and closes their file, a failure of either truncates the recording.
+func closeRecording(recorder *scurl.Recorder, f *os.File) error {
+	err := recorder.Flush()
+	if e := f.Close(); err == nil {
+		err = e
 	}
+	if err != nil {
+		return fmt.Errorf("recording %s: %s", f.Name(), err)
+	}
+
+	return nil
 }
 
 const example = `
@@ -185,15 +271,312 @@ func (f multipartForm) String() string {
 }
 
 type reqOpts struct {
-	verbose  bool
-	fanOut   int
-	rate     rateFlag
-	duration time.Duration
+	verbose     bool
+	live        bool
+	every       time.Duration
+	metricsAddr string
+	fanOut      int
+	rate        rateFlag
+	profile     profileFlag
+	workers     int
+	maxWorkers  int
+	users       int
+	think       thinkFlag
+	duration    time.Duration
+	maxErrors   int
+	stopOnError bool
+
+	timeout          time.Duration
+	dialTimeout      time.Duration
+	tlsTimeout       time.Duration
+	keepAlive        time.Duration
+	disableKeepAlive bool
+	protocol         scurl.Protocol
+	cookies          cookiesFlag
+	maxIdleConns     int
+	maxConnsPerHost  int
 
 	method  methodFlag
 	headers headers
 	body    string
 	form    multipartForm
+
+	output     outputFlag
+	outputFile string
+	record     string
+
+	thresholds     thresholdsFlag
+	thresholdsFile string
+
+	targets       string
+	targetsFormat string
+	scenarioFile  string
+	targeting     targetingFlag
+
+	expect   expectations
+	template bool
+
+	feeder          string
+	feederFormat    string
+	feederOrder     string
+	feederExhausted string
+}
+
+func newReqOpts() *reqOpts {
+	return &reqOpts{
+		headers:   headers{make([]string, 0)},
+		method:    methodFlag{},
+		rate:      rateFlag{&scurl.Rate{Freq: scurl.DefaultRate.Freq, Per: scurl.DefaultRate.Per}},
+		verbose:   false,
+		form:      multipartForm{map[string]string{}},
+		output:    outputFlag{"text"},
+		targeting: targetingFlag{"round-robin"},
+		profile:   profileFlag{spec: "constant"},
+		cookies:   cookiesFlag{scurl.NoCookies},
+	}
+}
+
+// register adds the flags describing the requests and the clients sending them, shared by the stress and the
+// rate search.
+func (o *reqOpts) register(fs *flag.FlagSet) {
+	fs.IntVar(&o.fanOut, "fo", 1, "Fan out factor is the number of clients to spawn")
+	fs.IntVar(&o.workers, "workers", 0, "Number of workers started upfront, more are spawned once all of them are busy (default 0)")
+	fs.IntVar(&o.maxWorkers, "max-workers", 0, fmt.Sprintf("Maximum number of workers sending requests [0 = unlimited for a paced -rate or -profile, %d at -rate max] (default 0)", scurl.DefaultMaxWorkers))
+	fs.Var(&o.method, "X", "HTTP method to use (default GET)")
+	fs.Var(&o.headers, "H", "HTTP header to add")
+	fs.StringVar(&o.body, "d", "", "HTTP body to transport")
+	fs.Var(&o.form, "F", "Add form-data in the format [key=value] (Content-Type is set to multipart/form-data)")
+	fs.BoolVar(&o.template, "template", false, "Render the url, headers and body as templates on every request, i.e. {{uuid}}, {{randInt 1 1000}}, {{seq}}, {{now}}, {{csv \"users.csv\" \"id\"}}")
+	fs.StringVar(&o.feeder, "feeder", "", "Dataset whose rows are available to the templates as {{.column}}, one row per request (implies -template)")
+	fs.StringVar(&o.feederFormat, "feeder-format", "", "Format of the feeder dataset [csv, json] (default based on the file extension)")
+	fs.StringVar(&o.feederOrder, "feeder-order", string(scurl.SequentialFeed), "Order the feeder rows are used in [sequential, random, circular]")
+	fs.StringVar(&o.feederExhausted, "feeder-exhausted", string(scurl.StopOnExhaustion), "What happens once the feeder ran out of rows [stop, wrap, error]")
+	o.expect.register(fs)
+	fs.StringVar(&o.scenarioFile, "scenario", "", "YAML file with the steps of a scenario every virtual user plays instead of a single '<url>'")
+	fs.StringVar(&o.targets, "targets", "", "File with the targets to stress instead of a single '<url>'")
+	fs.StringVar(&o.targetsFormat, "targets-format", "", "Format of the targets file [text, json] (default based on the file extension)")
+	fs.Var(&o.targeting, "targeting", "Strategy for picking the next target out of the targets file [round-robin, random, weighted]")
+	fs.DurationVar(&o.timeout, "timeout", 0, "Timeout of each request including reading the response body [0 = none] (default 0)")
+	fs.DurationVar(&o.dialTimeout, "dial-timeout", scurl.DefaultTransportConfig.DialTimeout, "Timeout for establishing a TCP connection, not with -http3")
+	fs.DurationVar(&o.tlsTimeout, "tls-handshake-timeout", scurl.DefaultTransportConfig.TLSHandshakeTimeout, "Timeout for the TLS handshake")
+	fs.DurationVar(&o.keepAlive, "keepalive", scurl.DefaultTransportConfig.KeepAlive, "TCP keep-alive period of open connections")
+	fs.Var(&o.cookies, "cookies", "Keep the cookies set by the target in jars, one per [none, shared, client, worker, user] (user = iteration of a -scenario)")
+	fs.BoolVar(&o.disableKeepAlive, "disable-keepalive", false, "Open a new connection for every request instead of reusing connections, not with -http3")
+	fs.Var(protocolFlag{&o.protocol, scurl.HTTP1}, "http1.1", "Speak HTTP/1.1 only, even if the target offers HTTP/2 over TLS")
+	fs.Var(protocolFlag{&o.protocol, scurl.HTTP2}, "http2", "Speak HTTP/2 over TLS only instead of negotiating the protocol")
+	fs.Var(protocolFlag{&o.protocol, scurl.H2C}, "h2c", "Speak HTTP/2 over cleartext TCP with prior knowledge, to targets with http URLs only")
+	fs.Var(protocolFlag{&o.protocol, scurl.HTTP3}, "http3", "Speak HTTP/3 over QUIC, to targets with https URLs only")
+	fs.IntVar(&o.maxIdleConns, "max-idle-conns", scurl.DefaultTransportConfig.MaxIdleConns, "Maximum number of idle connections kept open per host, not with -http3")
+	fs.IntVar(&o.maxConnsPerHost, "max-conns-per-host", 0, "Maximum number of connections per host, not with -http3 which opens a single one [0 = unlimited] (default 0)")
+	fs.IntVar(&o.maxErrors, "max-errors", 0, "Stop the stress after the given number of failed requests [0 = never] (default 0)")
+	fs.BoolVar(&o.stopOnError, "stop-on-error", false, "Stop the stress on the first failed request (same as -max-errors 1)")
+	fs.BoolVar(&o.verbose, "verbose", false, "Verbose logging")
+	fs.Var(&o.output, "output", "Format of the final report [text, json]")
+	fs.StringVar(&o.outputFile, "output-file", "", "File to write the final report to (default stdout)")
+}
+
+// validArgs reports whether exactly one of a single '<url>', the targets file and the scenario is given.
+func (o reqOpts) validArgs(args []string) bool {
+	sources := len(args)
+	if o.targets != "" {
+		sources++
+	}
+	if o.scenarioFile != "" {
+		sources++
+	}
+
+	return sources == 1 && len(args) <= 1
+}
+
+// tcpFlags configure TCP connections, they do not apply to the single QUIC connection per host of -http3
+var tcpFlags = []string{"dial-timeout", "disable-keepalive", "max-idle-conns", "max-conns-per-host"}
+
+// validFlags rejects the flags set on the command line which do not apply to the protocol spoken.
+func (o reqOpts) validFlags(fs *flag.FlagSet) error {
+	if o.protocol != scurl.HTTP3 {
+		return nil
+	}
+
+	var err error
+	fs.Visit(func(f *flag.Flag) {
+		for _, name := range tcpFlags {
+			if f.Name == name && err == nil {
+				err = fmt.Errorf("-%s does not apply to the QUIC connections of -http3", name)
+			}
+		}
+	})
+	return err
+}
+
+// clientOpts configure the clients sending the requests, the load is configured by the caller.
+func (o reqOpts) clientOpts() []func(*scurl.ConcurrentClient) {
+	return []func(*scurl.ConcurrentClient){
+		scurl.FanOutOpt(o.fanOut),
+		scurl.MaxErrorsOpt(o.errorLimit()),
+		scurl.WorkersOpt(o.workers),
+		scurl.MaxWorkersOpt(o.maxWorkers),
+		scurl.TimeoutOpt(o.timeout),
+		scurl.DialTimeoutOpt(o.dialTimeout),
+		scurl.TLSHandshakeTimeoutOpt(o.tlsTimeout),
+		scurl.KeepAliveOpt(o.keepAlive),
+		scurl.DisableKeepAliveOpt(o.disableKeepAlive),
+		scurl.MaxIdleConnsOpt(o.maxIdleConns),
+		scurl.MaxConnsPerHostOpt(o.maxConnsPerHost),
+		scurl.ProtocolOpt(o.protocol),
+		scurl.CookiesOpt(o.cookies.mode),
+		scurl.VerboseOpt(o.verbose),
+	}
+}
+
+// source is what the stress hits, either the targets of a Targeter or the steps of a scenario
+type source struct {
+	targeter scurl.Targeter
+	scenario *scurl.Scenario
+}
+
+func (s source) attack(client *scurl.ConcurrentClient) <-chan *scurl.Response {
+	if s.scenario != nil {
+		return client.DoScenario(s.scenario)
+	}
+
+	return client.DoReq(s.targeter)
+}
+
+// source creates the scenario if one is given, the Targeter otherwise.
+func (o reqOpts) source(args []string) (source, scurl.ReportParams, error) {
+	if o.scenarioFile != "" {
+		scenario, params, err := o.scenario()
+		return source{scenario: scenario}, params, err
+	}
+
+	targeter, params, err := o.targeter(args)
+	return source{targeter: targeter}, params, err
+}
+
+// targeter creates the Targeter of the stress, either out of the single url in args or the targets file,
+// along with the parameters describing it in the final report. With a feeder the targets are rendered with the
+// rows of the feeder.
+func (o reqOpts) targeter(args []string) (scurl.Targeter, scurl.ReportParams, error) {
+	if o.feeder == "" {
+		return o.unfedTargeter(args)
+	}
+
+	feeder, err := readFeeder(o.feeder, o.feederFormat, o.feederOrder, o.feederExhausted)
+	if err != nil {
+		return nil, scurl.ReportParams{}, err
+	}
+
+	o.template = true
+	targeter, params, err := o.unfedTargeter(args)
+	if err != nil {
+		return nil, params, err
+	}
+
+	return scurl.NewFeedTargeter(targeter, feeder), params, nil
+}
+
+func (o reqOpts) unfedTargeter(args []string) (scurl.Targeter, scurl.ReportParams, error) {
+	params := o.reportParams()
+
+	rules, err := o.expect.rules()
+	if err != nil {
+		return nil, params, err
+	}
+
+	if o.targets != "" {
+		targets, err := readTargets(o.targets, o.targetsFormat, o.headers.headers)
+		if err != nil {
+			return nil, params, err
+		}
+		for _, t := range targets {
+			t.Rules = append(t.Rules, rules...)
+			if o.template {
+				if err := scurl.TemplateOption()(t); err != nil {
+					return nil, params, fmt.Errorf("%s %s: %s", t.Method, t.URL, err)
+				}
+			}
+		}
+
+		params.Target = o.targets
+		params.Targets = len(targets)
+		return newTargeter(o.targeting.strategy, targets), params, nil
+	}
+
+	bodyOption, err := o.bodyOption()
+	if err != nil {
+		return nil, params, err
+	}
+
+	options := []scurl.ReqOption{
+		scurl.MethodOption(o.method.verb),
+		bodyOption,
+		scurl.HeaderOption(o.headers.headers...),
+		scurl.ExpectOption(rules...),
+	}
+	if o.template {
+		options = append(options, scurl.TemplateOption())
+	}
+
+	request, err := scurl.NewTarget(args[0], options...)
+	if err != nil {
+		return nil, params, err
+	}
+
+	params.Target = request.URL
+	params.Method = request.Method
+	params.Targets = 1
+	return request, params, nil
+}
+
+// reportParams describe the load of the stress in the final report, the rate of a closed loop is its result.
+func (o reqOpts) reportParams() scurl.ReportParams {
+	params := scurl.ReportParams{
+		Rate:     o.rate.val,
+		Profile:  o.profile.spec,
+		FanOut:   o.fanOut,
+		Duration: o.duration,
+	}
+	if o.rate.val.IsZero() && o.profile.pacer == nil {
+		params.Rate = nil
+		params.Profile = "max"
+	}
+	if o.users > 0 {
+		params.Rate = nil
+		params.Profile = "closed-loop"
+		params.Users = o.users
+		params.ThinkTime = &o.think.val
+	}
+
+	return params
+}
+
+// pacer returns the Pacer of the load profile, or the constant -rate unless a profile is given
+func (o reqOpts) pacer() scurl.Pacer {
+	if o.profile.pacer == nil {
+		if o.rate.val.IsZero() {
+			return scurl.MaxRate
+		}
+		return o.rate.val
+	}
+
+	return o.profile.pacer
+}
+
+// finish writes the final report to out and checks the thresholds against the final metrics.
+func (o reqOpts) finish(out *output, params scurl.ReportParams, m *scurl.Metrics) error {
+	if err := writeReport(out, o.output.format, params, m); err != nil {
+		return err
+	}
+
+	return checkThresholds(os.Stderr, o.thresholds.list, m)
+}
+
+func (o reqOpts) errorLimit() int {
+	if o.stopOnError {
+		return 1
+	}
+
+	return o.maxErrors
 }
 
 func (o reqOpts) bodyOption() (scurl.ReqOption, error) {
@@ -241,6 +624,81 @@ func (m *methodFlag) Set(val string) error {
 	return nil
 }
 
+// thinkFlag is the think time of the virtual users
+type thinkFlag struct {
+	val scurl.ThinkTime
+}
+
+func (t *thinkFlag) String() string {
+	return t.val.String()
+}
+
+// Set implements the flag.Value interface for think times.
+func (t *thinkFlag) Set(val string) error {
+	think, err := scurl.ParseThinkTime(val)
+	if err != nil {
+		return err
+	}
+
+	t.val = think
+	return nil
+}
+
+// protocolFlag is one of the boolean flags forcing the protocol of the clients, which cannot be combined
+type protocolFlag struct {
+	protocol *scurl.Protocol
+	val      scurl.Protocol
+}
+
+func (p protocolFlag) IsBoolFlag() bool {
+	return true
+}
+
+func (p protocolFlag) String() string {
+	return strconv.FormatBool(p.protocol != nil && *p.protocol == p.val)
+}
+
+// Set implements the flag.Value interface for forced protocols.
+func (p protocolFlag) Set(val string) error {
+	force, err := strconv.ParseBool(val)
+	if err != nil {
+		return err
+	}
+
+	if !force {
+		if *p.protocol == p.val {
+			*p.protocol = scurl.NegotiatedProtocol
+		}
+		return nil
+	}
+	if *p.protocol != scurl.NegotiatedProtocol && *p.protocol != p.val {
+		return fmt.Errorf("-%s cannot be combined with -%s", p.val, *p.protocol)
+	}
+
+	*p.protocol = p.val
+	return nil
+}
+
+// cookiesFlag is how the cookies of the targets are kept
+type cookiesFlag struct {
+	mode scurl.CookieMode
+}
+
+func (c *cookiesFlag) String() string {
+	return string(c.mode)
+}
+
+// Set implements the flag.Value interface for cookie modes.
+func (c *cookiesFlag) Set(val string) error {
+	switch mode := scurl.CookieMode(val); mode {
+	case scurl.NoCookies, scurl.SharedCookies, scurl.ClientCookies, scurl.WorkerCookies, scurl.UserCookies:
+		c.mode = mode
+		return nil
+	}
+
+	return fmt.Errorf("cookies '%s' is not supported, supported modes are [none shared client worker user]", val)
+}
+
 type rateFlag struct {
 	val *scurl.Rate
 }
@@ -249,12 +707,20 @@ func (r *rateFlag) String() string {
 	if r.val == nil {
 		return ""
 	}
+	if r.val.IsZero() {
+		return "max"
+	}
 
 	return r.val.String()
 }
 
 // Set implements the flag.Value interface for request rate limiting.
 func (r *rateFlag) Set(val string) error {
+	if val == "max" || val == "0" {
+		r.val.Freq, r.val.Per = 0, 0
+		return nil
+	}
+
 	parts := strings.Split(val, "/")
 
 	if len(parts) == 0 {
@@ -285,7 +751,7 @@ func (r *rateFlag) Set(val string) error {
 	r.val.Per = duration
 
 	if r.val.IsZero() {
-		return fmt.Errorf("-rate value cannot be zero, both freq and duration need to be > 0 (i.e. 50/1s)")
+		return fmt.Errorf("-rate value cannot be zero, both freq and duration need to be > 0 (i.e. 50/1s), use -rate max for no pacing")
 	}
 
 	return nil
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"github.com/newestuser/scurl/lib"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const profileFormats = `"constant", "linear:FROM:TO:OVER", "step:START:STEP:EVERY", "sine:MEAN:AMPLITUDE:PERIOD" or "schedule:FILE"`
+
+// profileFlag is the load profile pacing the requests, rates of the profile are in requests per second
+type profileFlag struct {
+	spec  string
+	pacer scurl.Pacer
+}
+
+func (p *profileFlag) String() string {
+	return p.spec
+}
+
+// Set implements the flag.Value interface for load profiles.
+func (p *profileFlag) Set(val string) error {
+	parts := strings.Split(val, ":")
+
+	switch {
+	case parts[0] == "constant" && len(parts) == 1:
+		p.pacer = nil
+
+	case parts[0] == "linear" && len(parts) == 4:
+		from, to, over, err := profileArgs(parts[1:])
+		if err != nil {
+			return err
+		}
+		if to <= 0 {
+			return fmt.Errorf("-profile linear rate TO %v is not positive", to)
+		}
+		p.pacer = &scurl.LinearPacer{From: from, To: to, Over: over}
+
+	case parts[0] == "step" && len(parts) == 4:
+		start, step, every, err := profileArgs(parts[1:])
+		if err != nil {
+			return err
+		}
+		p.pacer = &scurl.StepPacer{Start: start, Step: step, Every: every}
+
+	case parts[0] == "sine" && len(parts) == 4:
+		mean, amplitude, period, err := profileArgs(parts[1:])
+		if err != nil {
+			return err
+		}
+		if mean <= 0 {
+			return fmt.Errorf("-profile sine mean rate %v is not positive", mean)
+		}
+		if math.Abs(amplitude) > mean {
+			return fmt.Errorf("-profile sine amplitude %v cannot exceed the mean %v", amplitude, mean)
+		}
+		p.pacer = &scurl.SinePacer{Mean: mean, Amplitude: amplitude, Period: period}
+
+	case parts[0] == "schedule" && len(parts) == 2:
+		f, err := os.Open(parts[1])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		schedule, err := scurl.ReadSchedule(f)
+		if err != nil {
+			return fmt.Errorf("failed reading schedule %s: %s", parts[1], err)
+		}
+		p.pacer = schedule
+
+	default:
+		return fmt.Errorf("-profile %q does not match any of the %s formats", val, profileFormats)
+	}
+
+	p.spec = val
+	return nil
+}
+
+// profileArgs parses the two rates and the period of a load profile
+func profileArgs(args []string) (float64, float64, time.Duration, error) {
+	first, err := strconv.ParseFloat(args[0], 64)
+	if err != nil || first < 0 {
+		return 0, 0, 0, fmt.Errorf("-profile rate '%s' is not a positive number", args[0])
+	}
+
+	second, err := strconv.ParseFloat(args[1], 64)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("-profile rate '%s' is not a number", args[1])
+	}
+
+	period, err := time.ParseDuration(args[2])
+	if err != nil || period <= 0 {
+		return 0, 0, 0, fmt.Errorf("-profile period '%s' is not a positive duration (i.e. 30s)", args[2])
+	}
+
+	return first, second, period, nil
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/newestuser/scurl/lib"
+	"io"
+	"os"
+	"strings"
+)
+
+// histogramWidth is the number of characters of a bar representing 100% of the responses
+const histogramWidth = 50
+
+// outputFlag is the format of the final report
+type outputFlag struct {
+	format string
+}
+
+func (o *outputFlag) String() string {
+	return o.format
+}
+
+// Set implements the flag.Value interface for report formats.
+func (o *outputFlag) Set(val string) error {
+	switch val {
+	case "text", "json":
+		o.format = val
+		return nil
+	}
+
+	return fmt.Errorf("output format '%s' is not supported, supported formats are [text json]", val)
+}
+
+// output is where the final report is written to, the file given by -output-file or stdout. It remembers the
+// first failed write, which the text report does not check.
+type output struct {
+	io.Writer
+	file *os.File
+	err  error
+}
+
+// createOutput creates the file at path, or writes to stdout if path is empty. The file is created before the
+// attack so that a path which cannot be written does not waste the run.
+func createOutput(path string) (*output, error) {
+	if path == "" {
+		return &output{Writer: os.Stdout}, nil
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return &output{Writer: f, file: f}, nil
+}
+
+func (o *output) Write(p []byte) (int, error) {
+	n, err := o.Writer.Write(p)
+	if err != nil && o.err == nil {
+		o.err = err
+	}
+
+	return n, err
+}
+
+// Close closes the file, its error or the one of a failed write tells the report was not written entirely.
+func (o *output) Close() error {
+	err := o.err
+	if o.file != nil {
+		if e := o.file.Close(); err == nil {
+			err = e
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("writing report: %s", err)
+	}
+
+	return nil
+}
+
+// closeOutput closes out, reporting its error in err unless err is set already.
+func closeOutput(out *output, err *error) {
+	if e := out.Close(); e != nil && *err == nil {
+		*err = e
+	}
+}
+
+// writeReport writes the report in the requested format to w.
+func writeReport(w io.Writer, format string, params scurl.ReportParams, resp *scurl.Metrics) error {
+	if format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(scurl.NewReport(params, resp))
+	}
+
+	printResult(w, resp)
+	for _, name := range params.Steps {
+		if step, ok := resp.Steps[name]; ok {
+			fmt.Fprintf(w, "\nStep %s:\n", name)
+			printStep(w, step)
+		}
+	}
+	return nil
+}
+
+// printStep prints the summary of a step of a scenario
+func printStep(w io.Writer, step *scurl.Metrics) {
+	fmt.Fprintln(w, "\tTrips:", step.Trips)
+	if step.Latencies.Count() != 0 {
+		fmt.Fprintf(w, "\tLatency: mean %s, p50 %s, p99 %s, max %s\n", step.AvrTime(), step.Percentile(50), step.Percentile(99), step.Slowest())
+	}
+	for status, count := range step.StatusCodes {
+		fmt.Fprintf(w, "\tStatus %d: %d responses\n", status, count)
+	}
+	for class, count := range step.Errors {
+		fmt.Fprintf(w, "\t%s: %d errors\n", class, count)
+	}
+	for rule, count := range step.Invalid {
+		fmt.Fprintf(w, "\t%s: %d validation failures\n", rule, count)
+	}
+}
+
+func printResult(w io.Writer, resp *scurl.Metrics) {
+	fmt.Fprintln(w, "Trips:", resp.Trips)
+	if resp.Latencies.Count() != 0 {
+		fmt.Fprintln(w, "Total time:", resp.TotalTime())
+		fmt.Fprintf(w, "Throughput: %.2f/s\n", float64(resp.Trips)/resp.TotalTime().Seconds())
+		fmt.Fprintln(w, "Avr time:", resp.AvrTime())
+		fmt.Fprintln(w, "Fastest:", resp.Fastest())
+		fmt.Fprintln(w, "Slowest:", resp.Slowest())
+		fmt.Fprintln(w, "Latency percentiles:")
+		fmt.Fprintf(w, "\t%-20s%-16s %s\n", "", "service time", "response time (corrected for coordinated omission)")
+		service := resp.Percentiles(scurl.ReportPercentiles...)
+		response := resp.ResponseTimes.Percentiles(scurl.ReportPercentiles...)
+		for i, p := range scurl.ReportPercentiles {
+			fmt.Fprintf(w, "\t%-20s%-16s %s\n", fmt.Sprintf("p%v:", p), service[i], response[i])
+		}
+		fmt.Fprintf(w, "\t%-20s%-16s %s\n", "max:", resp.Slowest(), resp.ResponseTimes.Max())
+
+		fmt.Fprintln(w, "Latency histogram:")
+		for _, b := range resp.Histogram() {
+			share := float64(b.Count) / float64(resp.Latencies.Count())
+			fmt.Fprintf(w, "\t%-20s %8d %7.2f%% %s\n", b, b.Count, share*100, strings.Repeat("#", int(share*histogramWidth)))
+		}
+
+		fmt.Fprintln(w, "Total bytes:", resp.TotalBytes())
+		if resp.Handshakes.Count() != 0 {
+			fmt.Fprintf(w, "Handshakes: %d, mean %s, p99 %s, max %s\n", resp.Handshakes.Count(), resp.Handshakes.Mean(), resp.Handshakes.Percentile(99), resp.Handshakes.Max())
+		}
+
+		for status, count := range resp.StatusCodes {
+			fmt.Fprintf(w, "\tStatus %d: %d responses\n", status, count)
+		}
+		for proto, count := range resp.Protocols {
+			fmt.Fprintf(w, "\tProtocol %s: %d responses\n", proto, count)
+		}
+	}
+
+	if resp.Late != 0 {
+		fmt.Fprintf(w, "Late: %d requests waited for a busy worker (-max-workers reached)\n", resp.Late)
+	}
+
+	if len(resp.Errors) != 0 {
+		fmt.Fprintln(w, "Errors:", resp.ErrorCount())
+		for class, count := range resp.Errors {
+			fmt.Fprintf(w, "\t%s: %d errors\n", class, count)
+		}
+	}
+
+	if len(resp.Invalid) != 0 {
+		fmt.Fprintln(w, "Validation failures:", resp.InvalidCount())
+		for rule, count := range resp.Invalid {
+			fmt.Fprintf(w, "\t%s: %d responses\n", rule, count)
+		}
+	}
+}
+
+// reportCmd regenerates the report of one or more results files written with -record.
+func reportCmd(args []string) (err error) {
+	fs := flag.NewFlagSet("scurl report", flag.ExitOnError)
+
+	output := outputFlag{"text"}
+	fs.Var(&output, "output", "Format of the report [text, json]")
+	outputFile := fs.String("output-file", "", "File to write the report to (default stdout)")
+	thresholds := thresholdsFlag{}
+	fs.Var(&thresholds, "threshold", "Threshold the metrics have to satisfy or scurl exits with an error, may be repeated (i.e. p99<300ms)")
+	thresholdsFile := fs.String("thresholds", "", "File with one -threshold per line")
+
+	fs.Usage = func() {
+		fmt.Println("Usage: scurl report [report flags] <results file>...")
+		fmt.Printf("\nreport flags:\n")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if len(fs.Args()) == 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	if *thresholdsFile != "" {
+		if err := thresholds.read(*thresholdsFile); err != nil {
+			return err
+		}
+	}
+
+	var params scurl.ReportParams
+	metrics := &scurl.Metrics{}
+	for i, path := range fs.Args() {
+		p, err := readResults(path, metrics)
+		if err != nil {
+			return fmt.Errorf("%s: %s", path, err)
+		}
+		if i == 0 {
+			params = p
+		}
+	}
+
+	out, err := createOutput(*outputFile)
+	if err != nil {
+		return err
+	}
+	defer closeOutput(out, &err)
+
+	if err := writeReport(out, output.format, params, metrics); err != nil {
+		return err
+	}
+
+	return checkThresholds(os.Stderr, thresholds.list, metrics)
+}
+
+// readResults adds the results recorded in the file at path to the metrics.
+func readResults(path string, metrics *scurl.Metrics) (scurl.ReportParams, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return scurl.ReportParams{}, err
+	}
+	defer f.Close()
+
+	reader, err := scurl.NewRecordReader(f)
+	if err != nil {
+		return scurl.ReportParams{}, err
+	}
+
+	for {
+		r, err := reader.Next()
+		if err == io.EOF {
+			return reader.Params(), nil
+		}
+		if err != nil {
+			return reader.Params(), err
+		}
+		metrics.Add(r)
+	}
+}
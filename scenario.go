@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"github.com/newestuser/scurl/lib"
+	"os"
+)
+
+// scenario reads the scenario file along with the parameters describing it in the final report. The rows of a
+// feeder become the initial values of every virtual user, the -expect rules apply to every step.
+func (o reqOpts) scenario() (*scurl.Scenario, scurl.ReportParams, error) {
+	params := o.reportParams()
+	params.Target = o.scenarioFile
+
+	rules, err := o.expect.rules()
+	if err != nil {
+		return nil, params, err
+	}
+
+	f, err := os.Open(o.scenarioFile)
+	if err != nil {
+		return nil, params, err
+	}
+	defer f.Close()
+
+	s, err := scurl.ReadScenario(f)
+	if err != nil {
+		return nil, params, fmt.Errorf("%s: %s", o.scenarioFile, err)
+	}
+	for _, step := range s.Steps {
+		step.Target.Rules = append(step.Target.Rules, rules...)
+	}
+
+	if o.feeder != "" {
+		if s.Feeder, err = readFeeder(o.feeder, o.feederFormat, o.feederOrder, o.feederExhausted); err != nil {
+			return nil, params, err
+		}
+	}
+
+	params.Targets = len(s.Steps)
+	params.Steps = s.StepNames()
+	return s, params, nil
+}
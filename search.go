@@ -0,0 +1,182 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/newestuser/scurl/lib"
+	"io"
+	"os"
+	"os/signal"
+	"runtime"
+	"strings"
+	"time"
+)
+
+var errSearchInterrupted = errors.New("search interrupted")
+
+// searchCmd searches for the highest rate the target sustains while meeting the SLO given by the thresholds.
+func searchCmd(args []string) (err error) {
+	fs := flag.NewFlagSet("scurl search", flag.ExitOnError)
+
+	opts := newReqOpts()
+	opts.register(fs)
+	search := &scurl.RateSearch{}
+	fs.IntVar(&search.Min, "min", 10, "Lowest rate to try in requests per second")
+	fs.IntVar(&search.Max, "max", 1000, "Highest rate to try in requests per second")
+	fs.IntVar(&search.Precision, "precision", 1, "The search ends once the highest passing and the lowest failing rate are this many requests per second apart")
+	phase := fs.Duration("phase", 10*time.Second, "Duration of every phase run at a constant rate")
+	fs.Var(&opts.thresholds, "threshold", "Threshold of the SLO every phase has to satisfy for its rate to pass, may be repeated (i.e. p99<300ms, error_rate<1%)")
+	fs.StringVar(&opts.thresholdsFile, "thresholds", "", "File with one -threshold per line")
+
+	fs.Usage = func() {
+		fmt.Println("Usage: scurl search [search flags] '<url>'")
+		fmt.Println("       scurl search [search flags] -targets <file>")
+		fmt.Println("       scurl search [search flags] -scenario <file>")
+		fmt.Printf("\nsearch flags:\n")
+		fs.PrintDefaults()
+		fmt.Print(searchExample)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if !opts.validArgs(fs.Args()) {
+		fs.Usage()
+		os.Exit(1)
+	}
+	if err := opts.validFlags(fs); err != nil {
+		return err
+	}
+	if opts.thresholdsFile != "" {
+		if err := opts.thresholds.read(opts.thresholdsFile); err != nil {
+			return err
+		}
+	}
+	if len(opts.thresholds.list) == 0 {
+		return fmt.Errorf("the SLO of the search needs at least one -threshold")
+	}
+	if *phase <= 0 {
+		return fmt.Errorf("-phase has to be positive")
+	}
+	search.SLO = opts.thresholds.list
+
+	src, params, err := opts.source(fs.Args())
+	if err != nil {
+		return err
+	}
+	params.Profile = "constant"
+	params.Duration = *phase
+
+	out, err := createOutput(opts.outputFile)
+	if err != nil {
+		return err
+	}
+	defer closeOutput(out, &err)
+
+	runtime.GOMAXPROCS(runtime.NumCPU())
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt)
+	defer signal.Stop(sig)
+
+	result, err := search.Run(func(rate *scurl.Rate) (*scurl.Metrics, error) {
+		client := scurl.NewConcurrentClient(append(opts.clientOpts(),
+			scurl.RateOpt(rate),
+			scurl.DurationOpt(*phase),
+		)...)
+		defer client.Close()
+
+		m, err := runPhase(client, src, sig)
+		if err == nil {
+			fmt.Fprintf(os.Stderr, "Phase at %s: %d trips, p99 %s\n", rate, m.Trips, m.ResponseTimes.Percentile(99).Round(time.Microsecond))
+		}
+		return m, err
+	})
+	if result == nil {
+		return err
+	}
+
+	if e := writeSearch(out, opts.output.format, params, search.SLO, result); e != nil {
+		return e
+	}
+	if err != nil {
+		return err
+	}
+	if result.Rate == nil {
+		return fmt.Errorf("not even the lowest rate %d/1s satisfied the SLO", search.Min)
+	}
+
+	return nil
+}
+
+// runPhase runs the attack of a phase to its end and returns its metrics.
+func runPhase(client *scurl.ConcurrentClient, src source, sig <-chan os.Signal) (*scurl.Metrics, error) {
+	metrics := scurl.NewMetrics()
+	res := src.attack(client)
+
+	for {
+		select {
+		case <-sig:
+			client.Stop()
+			return nil, errSearchInterrupted
+		case r, ok := <-res:
+			if !ok {
+				if e := client.Err(); e != nil {
+					return nil, fmt.Errorf("phase ended early: %s", e)
+				}
+				return metrics, nil
+			}
+
+			metrics.Add(r)
+		}
+	}
+}
+
+// writeSearch writes the outcome of the search in the requested format to w.
+func writeSearch(w io.Writer, format string, params scurl.ReportParams, slo []*scurl.Threshold, result *scurl.SearchResult) error {
+	if format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false)
+		return enc.Encode(scurl.NewSearchReport(params, slo, result))
+	}
+
+	printSearch(w, result)
+	return nil
+}
+
+// printSearch prints the table of the phases in the order they ran followed by the rate found. The latencies are
+// the response times the SLO is checked against.
+func printSearch(w io.Writer, result *scurl.SearchResult) {
+	fmt.Fprintln(w, "Phases:")
+	fmt.Fprintf(w, "\t%-12s %8s %12s %10s %10s %8s  %s\n", "rate", "trips", "throughput", "p50", "p99", "errors", "result")
+	for _, p := range result.Phases {
+		m := p.Metrics
+		throughput, errorRate := 0.0, 0.0
+		if elapsed := m.TotalTime(); elapsed > 0 {
+			throughput = float64(m.Trips) / elapsed.Seconds()
+		}
+		if m.Trips != 0 {
+			errorRate = float64(m.ErrorCount()) / float64(m.Trips) * 100
+		}
+
+		outcome := "pass"
+		if !p.Passed() {
+			outcome = "FAIL " + strings.Join(p.Violations, ", ")
+		}
+		fmt.Fprintf(w, "\t%-12s %8d %10.2f/s %10s %10s %7.2f%%  %s\n", p.Rate, m.Trips, throughput, m.ResponseTimes.Percentile(50).Round(time.Microsecond), m.ResponseTimes.Percentile(99).Round(time.Microsecond), errorRate, outcome)
+	}
+
+	if result.Rate == nil {
+		fmt.Fprintln(w, "Highest rate meeting the SLO: none")
+		return
+	}
+	fmt.Fprintln(w, "Highest rate meeting the SLO:", result.Rate)
+}
+
+const searchExample = `
+example:
+	scurl search -min 50 -max 5000 -phase 15s -threshold 'p99<300ms' -threshold 'error_rate<1%' 'http://localhost:8080'
+`
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"github.com/newestuser/scurl/lib"
+	"os"
+	"path/filepath"
+)
+
+// targetingFlag is the strategy of picking the next target out of a targets file
+type targetingFlag struct {
+	strategy string
+}
+
+func (t *targetingFlag) String() string {
+	return t.strategy
+}
+
+// Set implements the flag.Value interface for targeting strategies.
+func (t *targetingFlag) Set(val string) error {
+	switch val {
+	case "round-robin", "random", "weighted":
+		t.strategy = val
+		return nil
+	}
+
+	return fmt.Errorf("targeting '%s' is not supported, supported strategies are [round-robin random weighted]", val)
+}
+
+// readTargets reads the targets file at path, whose format is derived from its extension unless provided, and
+// adds the given headers to each of the targets.
+func readTargets(path string, format string, headers []string) ([]*scurl.Target, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if format == "" {
+		format = "text"
+		if ext := filepath.Ext(path); ext == ".json" || ext == ".jsonl" {
+			format = "json"
+		}
+	}
+
+	var targets []*scurl.Target
+	switch format {
+	case "text":
+		targets, err = scurl.ReadTextTargets(f, filepath.Dir(path))
+	case "json":
+		targets, err = scurl.ReadJSONTargets(f)
+	default:
+		return nil, fmt.Errorf("targets format '%s' is not supported, supported formats are [text json]", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed reading targets from %s: %s", path, err)
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("no targets found in %s", path)
+	}
+
+	for _, t := range targets {
+		if err := scurl.HeaderOption(headers...)(t); err != nil {
+			return nil, err
+		}
+	}
+
+	return targets, nil
+}
+
+func newTargeter(strategy string, targets []*scurl.Target) scurl.Targeter {
+	switch strategy {
+	case "random":
+		return scurl.NewRandomTargeter(targets...)
+	case "weighted":
+		return scurl.NewWeightedTargeter(targets...)
+	}
+
+	return scurl.NewRoundRobinTargeter(targets...)
+}
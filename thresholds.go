@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"github.com/newestuser/scurl/lib"
+	"io"
+	"os"
+	"strings"
+)
+
+// thresholdsFlag collects the thresholds the final metrics have to satisfy
+type thresholdsFlag struct {
+	list []*scurl.Threshold
+}
+
+func (t *thresholdsFlag) String() string {
+	specs := make([]string, len(t.list))
+	for i, th := range t.list {
+		specs[i] = th.String()
+	}
+
+	return strings.Join(specs, ", ")
+}
+
+// Set implements the flag.Value interface for thresholds, adding one threshold per flag.
+func (t *thresholdsFlag) Set(val string) error {
+	th, err := scurl.ParseThreshold(val)
+	if err != nil {
+		return err
+	}
+
+	t.list = append(t.list, th)
+	return nil
+}
+
+// read adds the thresholds listed in the file at path.
+func (t *thresholdsFlag) read(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	list, err := scurl.ReadThresholds(f)
+	if err != nil {
+		return fmt.Errorf("%s: %s", path, err)
+	}
+
+	t.list = append(t.list, list...)
+	return nil
+}
+
+// checkThresholds prints whether each threshold passed and returns an error if any of them failed.
+func checkThresholds(w io.Writer, thresholds []*scurl.Threshold, m *scurl.Metrics) error {
+	if len(thresholds) == 0 {
+		return nil
+	}
+
+	failed := 0
+	fmt.Fprintln(w, "Thresholds:")
+	for _, th := range thresholds {
+		actual, ok := th.Check(m)
+		result := "pass"
+		if !ok {
+			result = "FAIL"
+			failed++
+		}
+		fmt.Fprintf(w, "\t%s %-20s (actual %s)\n", result, th, th.Format(actual))
+	}
+
+	if failed != 0 {
+		return fmt.Errorf("%d of %d thresholds failed", failed, len(thresholds))
+	}
+
+	return nil
+}